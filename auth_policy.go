@@ -0,0 +1,32 @@
+package main
+
+import (
+	"api-service/policy"
+	"context"
+
+	authpb "api-service/proto/auth-service/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var interactionActions = map[authpb.InteractionAction]string{
+	authpb.InteractionAction_INTERACTION_ACTION_DIRECT_MESSAGE: policy.ActionDirectMessage,
+	authpb.InteractionAction_INTERACTION_ACTION_ADD_TO_GROUP:   policy.ActionAddToGroup,
+	authpb.InteractionAction_INTERACTION_ACTION_SEND_MESSAGE:   policy.ActionSendMessage,
+}
+
+// CanInteract проверяет политику взаимодействия для chat-service
+func (s *AuthService) CanInteract(ctx context.Context, req *authpb.CanInteractRequest) (*authpb.CanInteractResponse, error) {
+	action, ok := interactionActions[req.GetAction()]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "unsupported action")
+	}
+
+	decision, err := policy.CanInteract(ctx, s.DB, int(req.GetActorId()), int(req.GetTargetId()), action)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check policy: %v", err)
+	}
+
+	return &authpb.CanInteractResponse{Allowed: decision.Allowed, Reason: decision.Reason}, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"api-service/model"
+	"context"
+	"database/sql"
+	"errors"
+
+	authpb "api-service/proto/auth-service/proto"
+
+	"github.com/uptrace/bun"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Максимальное количество ID в одном пакетном запросе
+const maxBatchUsers = 500
+
+func publicUserToProto(u model.PublicUser) *authpb.PublicUser {
+	pb := &authpb.PublicUser{
+		Id:     u.ID,
+		Name:   u.Name,
+		Avatar: u.Avatar,
+		Status: u.Status,
+		Bio:    u.Bio,
+	}
+	if !u.CreatedAt.IsZero() {
+		pb.CreatedAt = timestamppb.New(u.CreatedAt)
+	}
+	if !u.LastSeen.IsZero() {
+		pb.LastSeen = timestamppb.New(u.LastSeen)
+	}
+	return pb
+}
+
+// loadPublicUsers возвращает профили найденных пользователей: сначала из кэша,
+// остальные одним запросом к базе. Заблокированные администрацией считаются
+// несуществующими, как и в policy.CanInteract; при блокировке кэш сбрасывается
+func (s *AuthService) loadPublicUsers(ctx context.Context, ids []int32) (map[int32]model.PublicUser, error) {
+	result := make(map[int32]model.PublicUser, len(ids))
+	missing := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		if _, seen := result[id]; seen {
+			continue
+		}
+		if s.Users != nil {
+			if u, ok := s.Users.Get(id); ok {
+				result[id] = u
+				continue
+			}
+		}
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	var users []model.User
+	err := s.DB.NewSelect().
+		Model(&users).
+		Column("id", "name", "avatar", "status", "bio", "created_at", "last_seen").
+		Where("id IN (?)", bun.In(missing)).
+		Where("suspended_at IS NULL").
+		Scan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	for i := range users {
+		public := users[i].Public()
+		result[public.ID] = public
+		if s.Users != nil {
+			s.Users.Set(public.ID, public)
+		}
+	}
+	return result, nil
+}
+
+func validateBatch(ids []int32) error {
+	if len(ids) == 0 {
+		return status.Error(codes.InvalidArgument, "user_ids is empty")
+	}
+	if len(ids) > maxBatchUsers {
+		return status.Errorf(codes.InvalidArgument, "too many user_ids: %d, max %d", len(ids), maxBatchUsers)
+	}
+	return nil
+}
+
+// GetUser возвращает публичный профиль пользователя
+func (s *AuthService) GetUser(ctx context.Context, req *authpb.GetUserRequest) (*authpb.GetUserResponse, error) {
+	users, err := s.loadPublicUsers(ctx, []int32{req.GetUserId()})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load user: %v", err)
+	}
+	u, ok := users[req.GetUserId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return &authpb.GetUserResponse{User: publicUserToProto(u)}, nil
+}
+
+// BatchGetUsers возвращает профили в порядке запроса; ненайденные ID перечисляются в missing_ids
+func (s *AuthService) BatchGetUsers(ctx context.Context, req *authpb.BatchGetUsersRequest) (*authpb.BatchGetUsersResponse, error) {
+	if err := validateBatch(req.GetUserIds()); err != nil {
+		return nil, err
+	}
+
+	users, err := s.loadPublicUsers(ctx, req.GetUserIds())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load users: %v", err)
+	}
+
+	resp := &authpb.BatchGetUsersResponse{}
+	seen := make(map[int32]bool, len(req.GetUserIds()))
+	for _, id := range req.GetUserIds() {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if u, ok := users[id]; ok {
+			resp.Users = append(resp.Users, publicUserToProto(u))
+		} else {
+			resp.MissingIds = append(resp.MissingIds, id)
+		}
+	}
+	return resp, nil
+}
+
+// CheckUsersExist проверяет существование пользователей
+func (s *AuthService) CheckUsersExist(ctx context.Context, req *authpb.CheckUsersExistRequest) (*authpb.CheckUsersExistResponse, error) {
+	if err := validateBatch(req.GetUserIds()); err != nil {
+		return nil, err
+	}
+
+	users, err := s.loadPublicUsers(ctx, req.GetUserIds())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check users: %v", err)
+	}
+
+	resp := &authpb.CheckUsersExistResponse{Exists: make(map[int32]bool, len(req.GetUserIds())), AllExist: true}
+	for _, id := range req.GetUserIds() {
+		_, ok := users[id]
+		resp.Exists[id] = ok
+		if !ok {
+			resp.AllExist = false
+		}
+	}
+	return resp, nil
+}
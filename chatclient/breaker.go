@@ -0,0 +1,87 @@
+package chatclient
+
+import (
+	"sync"
+	"time"
+)
+
+// Состояния circuit breaker
+const (
+	stateClosed   = iota // Вызовы проходят
+	stateOpen            // Вызовы отклоняются сразу
+	stateHalfOpen        // Пропускается один пробный вызов
+)
+
+// breaker размыкается после threshold подряд идущих сбоев и через cooldown
+// пропускает пробный вызов: успех замыкает цепь, сбой снова размыкает
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+
+	state    int
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow сообщает, можно ли выполнить вызов
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = stateHalfOpen
+		b.probing = true
+		return true
+	case stateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record учитывает результат вызова
+func (b *breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.state = stateClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		b.state = stateOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// isOpen — цепь разомкнута и время ожидания ещё не вышло
+func (b *breaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == stateOpen && time.Since(b.openedAt) < b.cooldown
+}
+
+// release снимает пробный вызов без учёта результата, например при отмене клиентом
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
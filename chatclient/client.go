@@ -0,0 +1,242 @@
+package chatclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"sync/atomic"
+	"time"
+
+	chatpb "api-service/proto/chat-service/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/health" // Включает клиентскую проверку здоровья по healthCheckConfig
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Имя сервиса в протоколе gRPC health
+const serviceName = "chat.ChatService"
+
+// ErrUnavailable возвращается без обращения к сети, пока цепь разомкнута
+var ErrUnavailable = status.Error(codes.Unavailable, "chat-service временно недоступен")
+
+// Client — клиент chat-service с таймаутами, повторами идемпотентных вызовов,
+// circuit breaker и отслеживанием здоровья сервиса
+type Client struct {
+	chatpb.ChatServiceClient
+
+	cfg     Config
+	conn    *grpc.ClientConn
+	breaker *breaker
+	healthy atomic.Bool
+	cancel  context.CancelFunc
+}
+
+// New создаёт клиента. Подключение ленивое: недоступный chat-service не мешает старту.
+func New(cfg Config) (*Client, error) {
+	defaults := DefaultConfig(cfg.Addr)
+	if cfg.DefaultTimeout <= 0 {
+		cfg.DefaultTimeout = defaults.DefaultTimeout
+	}
+	if cfg.RetryMaxAttempts < 2 {
+		cfg.RetryMaxAttempts = defaults.RetryMaxAttempts
+	}
+	if cfg.RetryInitialBackoff <= 0 {
+		cfg.RetryInitialBackoff = defaults.RetryInitialBackoff
+	}
+	if cfg.RetryMaxBackoff <= 0 {
+		cfg.RetryMaxBackoff = defaults.RetryMaxBackoff
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = defaults.BreakerThreshold
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = defaults.BreakerCooldown
+	}
+	if cfg.MaxMessageSize <= 0 {
+		cfg.MaxMessageSize = defaults.MaxMessageSize
+	}
+
+	c := &Client{cfg: cfg, breaker: newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)}
+	c.healthy.Store(true)
+
+	serviceConfig, err := buildServiceConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(cfg.MaxMessageSize),
+			grpc.MaxCallSendMsgSize(cfg.MaxMessageSize),
+		),
+		grpc.WithChainUnaryInterceptor(c.unaryInterceptor),
+		grpc.WithChainStreamInterceptor(c.streamInterceptor),
+	}
+	conn, err := grpc.NewClient(cfg.Addr, append(opts, cfg.DialOptions...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat-service client: %w", err)
+	}
+
+	c.conn = conn
+	c.ChatServiceClient = chatpb.NewChatServiceClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	go c.watchHealth(ctx)
+
+	return c, nil
+}
+
+// Available сообщает, можно ли сейчас обращаться к chat-service
+func (c *Client) Available() bool {
+	return c.healthy.Load() && !c.breaker.isOpen()
+}
+
+// Close останавливает отслеживание здоровья и закрывает соединение
+func (c *Client) Close() error {
+	c.cancel()
+	return c.conn.Close()
+}
+
+// buildServiceConfig описывает повторы идемпотентных методов и проверку здоровья
+func buildServiceConfig(cfg Config) (string, error) {
+	type methodName struct {
+		Service string `json:"service"`
+		Method  string `json:"method"`
+	}
+
+	names := make([]methodName, 0, len(idempotentMethods))
+	for _, m := range idempotentMethods {
+		names = append(names, methodName{Service: serviceName, Method: m})
+	}
+
+	seconds := func(d time.Duration) string { return fmt.Sprintf("%.3fs", d.Seconds()) }
+
+	sc := map[string]interface{}{
+		"loadBalancingConfig": []map[string]interface{}{{"round_robin": map[string]interface{}{}}},
+		"healthCheckConfig":   map[string]string{"serviceName": serviceName},
+		"methodConfig": []map[string]interface{}{{
+			"name": names,
+			"retryPolicy": map[string]interface{}{
+				"maxAttempts":          cfg.RetryMaxAttempts,
+				"initialBackoff":       seconds(cfg.RetryInitialBackoff),
+				"maxBackoff":           seconds(cfg.RetryMaxBackoff),
+				"backoffMultiplier":    2,
+				"retryableStatusCodes": []string{"UNAVAILABLE"},
+			},
+		}},
+	}
+
+	data, err := json.Marshal(sc)
+	if err != nil {
+		return "", fmt.Errorf("failed to build service config: %w", err)
+	}
+	return string(data), nil
+}
+
+func (c *Client) timeoutFor(method string) time.Duration {
+	if d, ok := c.cfg.MethodTimeouts[path.Base(method)]; ok && d > 0 {
+		return d
+	}
+	return c.cfg.DefaultTimeout
+}
+
+// Сбоями инфраструктуры считаются только недоступность и таймауты;
+// прикладные ошибки (NotFound, InvalidArgument и т.п.) цепь не размыкают
+func isInfraFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Client) recordResult(err error) {
+	if status.Code(err) == codes.Canceled {
+		c.breaker.release()
+		return
+	}
+	c.breaker.record(isInfraFailure(err))
+}
+
+func (c *Client) unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !c.breaker.allow() {
+		return ErrUnavailable
+	}
+
+	// Таймаут покрывает все повторы; более ранний дедлайн вызывающего сохраняется
+	ctx, cancel := context.WithTimeout(ctx, c.timeoutFor(method))
+	defer cancel()
+
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	c.recordResult(err)
+	return err
+}
+
+// Потоки долгоживущие, поэтому без таймаута; breaker учитывает только открытие потока
+func (c *Client) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if !c.breaker.allow() {
+		return nil, ErrUnavailable
+	}
+
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	c.recordResult(err)
+	return stream, err
+}
+
+// watchHealth подписывается на статус chat-service по протоколу gRPC health
+// и переподключается при обрыве
+func (c *Client) watchHealth(ctx context.Context) {
+	client := healthpb.NewHealthClient(c.conn)
+	backoff := time.Second
+
+	for ctx.Err() == nil {
+		stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: serviceName})
+		for err == nil {
+			var resp *healthpb.HealthCheckResponse
+			resp, err = stream.Recv()
+			if err != nil {
+				break
+			}
+			backoff = time.Second
+			serving := resp.GetStatus() == healthpb.HealthCheckResponse_SERVING
+			if c.healthy.Swap(serving) != serving {
+				log.Printf("chat-service: статус здоровья %s", resp.GetStatus())
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch status.Code(err) {
+		case codes.Unimplemented:
+			// Сервер не поддерживает health — полагаемся только на circuit breaker
+			c.healthy.Store(true)
+			return
+		case codes.NotFound:
+			c.healthy.Store(false)
+		default:
+			if c.healthy.Swap(false) {
+				log.Printf("chat-service недоступен: %v", err)
+			}
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+}
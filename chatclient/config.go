@@ -0,0 +1,54 @@
+package chatclient
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Настройки клиента chat-service
+type Config struct {
+	Addr string // Адрес chat-service, например chat-service:50052
+
+	DefaultTimeout time.Duration            // Таймаут unary-вызова по умолчанию
+	MethodTimeouts map[string]time.Duration // Таймауты по имени метода, например "GetMessages"
+
+	RetryMaxAttempts    int           // Всего попыток для идемпотентных вызовов (включая первую)
+	RetryInitialBackoff time.Duration // Задержка перед первым повтором
+	RetryMaxBackoff     time.Duration // Верхняя граница задержки
+
+	BreakerThreshold int           // Подряд идущих сбоев до размыкания
+	BreakerCooldown  time.Duration // Через сколько пропустить пробный вызов
+
+	MaxMessageSize int // Максимальный размер сообщения в байтах
+
+	DialOptions []grpc.DialOption // Дополнительные опции подключения, например диалер bufconn в тестах
+}
+
+// DefaultConfig возвращает настройки по умолчанию для адреса addr
+func DefaultConfig(addr string) Config {
+	return Config{
+		Addr:           addr,
+		DefaultTimeout: 3 * time.Second,
+		MethodTimeouts: map[string]time.Duration{
+			"GetMessages": 5 * time.Second,
+			"SendMessage": 5 * time.Second,
+		},
+		RetryMaxAttempts:    3,
+		RetryInitialBackoff: 100 * time.Millisecond,
+		RetryMaxBackoff:     time.Second,
+		BreakerThreshold:    5,
+		BreakerCooldown:     10 * time.Second,
+		MaxMessageSize:      10 * 1024 * 1024, // 10 MB
+	}
+}
+
+// Идемпотентные методы: их безопасно повторять при UNAVAILABLE
+var idempotentMethods = []string{
+	"GetChat",
+	"ListUserChats",
+	"GetMessages",
+	"GetMessage",
+	"ListChatParticipants",
+	"MarkMessageAsRead",
+}
@@ -0,0 +1,246 @@
+// Package config загружает настройки сервиса: значения по умолчанию,
+// затем необязательный YAML-файл (CONFIG_FILE), затем переменные окружения.
+// Любую переменную можно задать через файл: NAME_FILE=/run/secrets/name (Docker secrets).
+package config
+
+import (
+	"api-service/model"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Минимальная длина ключей подписи
+const minSecretLength = 16
+
+// Максимальная длина названия реакции
+const maxReactionLength = 32
+
+type Config struct {
+	HTTP struct {
+		Addr string `yaml:"addr"`
+	} `yaml:"http"`
+
+	GRPC struct {
+		Addr string `yaml:"addr"`
+	} `yaml:"grpc"`
+
+	Database struct {
+		DSN         string `yaml:"dsn"`
+		AutoMigrate bool   `yaml:"auto_migrate"` // Применять миграции при старте
+		Debug       bool   `yaml:"debug"`        // Логировать SQL-запросы
+	} `yaml:"database"`
+
+	Auth struct {
+		JWTSecret string        `yaml:"jwt_secret"`
+		TokenTTL  time.Duration `yaml:"token_ttl"`
+	} `yaml:"auth"`
+
+	Chat struct {
+		Addr           string        `yaml:"addr"`
+		Timeout        time.Duration `yaml:"timeout"`
+		AllowedOrigins []string      `yaml:"allowed_origins"` // Сайты, с которых можно открыть WebSocket чата, кроме своего
+	} `yaml:"chat"`
+
+	Storage struct {
+		Driver    string        `yaml:"driver"` // local или s3
+		Dir       string        `yaml:"dir"`
+		URLSecret string        `yaml:"url_secret"` // Ключ подписи ссылок на файлы
+		URLTTL    time.Duration `yaml:"url_ttl"`
+		S3        struct {
+			Endpoint  string `yaml:"endpoint"`
+			AccessKey string `yaml:"access_key"`
+			SecretKey string `yaml:"secret_key"`
+			Bucket    string `yaml:"bucket"`
+			Region    string `yaml:"region"`
+			UseSSL    bool   `yaml:"use_ssl"`
+		} `yaml:"s3"`
+	} `yaml:"storage"`
+
+	Posts struct {
+		EditWindow     time.Duration `yaml:"edit_window"`     // Сколько после публикации пост можно редактировать; 0 — без ограничения
+		TrashRetention time.Duration `yaml:"trash_retention"` // Сколько удалённое хранится в корзине
+		Reactions      []string      `yaml:"reactions"`       // Допустимые реакции на посты; like обязательна
+	} `yaml:"posts"`
+
+	Comments struct {
+		MaxDepth int `yaml:"max_depth"` // Максимальная вложенность ответов; 0 — ответы запрещены
+	} `yaml:"comments"`
+
+	Jobs struct {
+		Workers           int    `yaml:"workers"`
+		ReconcileCounters string `yaml:"reconcile_counters"` // Cron-расписание сверки счётчиков постов; пусто — не запускать
+		PurgeTrash        string `yaml:"purge_trash"`        // Cron-расписание очистки корзины
+		PublishScheduled  string `yaml:"publish_scheduled"`  // Cron-расписание публикации просроченных отложенных постов
+	} `yaml:"jobs"`
+}
+
+// Default возвращает настройки по умолчанию. DSN и секреты по умолчанию не задаются.
+func Default() *Config {
+	cfg := &Config{}
+	cfg.HTTP.Addr = ":8080"
+	cfg.GRPC.Addr = ":50051"
+	cfg.Database.AutoMigrate = true
+	cfg.Auth.TokenTTL = 12 * time.Hour
+	cfg.Chat.Addr = "chat-service:50052"
+	cfg.Chat.Timeout = 3 * time.Second
+	cfg.Storage.Driver = "local"
+	cfg.Storage.Dir = "./uploads"
+	cfg.Storage.URLTTL = 15 * time.Minute
+	cfg.Posts.TrashRetention = 30 * 24 * time.Hour
+	cfg.Posts.Reactions = []string{model.ReactionLike, "love", "haha", "wow", "sad", "angry"}
+	cfg.Comments.MaxDepth = 3
+	cfg.Jobs.Workers = 4
+	cfg.Jobs.ReconcileCounters = "30 3 * * *"
+	cfg.Jobs.PurgeTrash = "15 * * * *"
+	cfg.Jobs.PublishScheduled = "* * * * *"
+	return cfg
+}
+
+// ValidationError перечисляет все найденные проблемы конфигурации
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load читает конфигурацию и проверяет её
+func Load() (*Config, error) {
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	// Отдельный ключ для ссылок на файлы не обязателен
+	if cfg.Storage.URLSecret == "" {
+		cfg.Storage.URLSecret = cfg.Auth.JWTSecret
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true) // Опечатка в ключе — ошибка, а не молча проигнорированное значение
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate проверяет конфигурацию и возвращает все проблемы сразу
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.HTTP.Addr == "" {
+		add("HTTP_ADDR is required")
+	}
+	if c.GRPC.Addr == "" {
+		add("GRPC_ADDR is required")
+	}
+	if c.Chat.Addr == "" {
+		add("CHAT_SERVICE_ADDR is required")
+	}
+	if c.Chat.Timeout <= 0 {
+		add("CHAT_SERVICE_TIMEOUT must be positive")
+	}
+	for _, origin := range c.Chat.AllowedOrigins {
+		if u, err := url.Parse(origin); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			add("CHAT_WS_ALLOWED_ORIGINS: invalid origin %q, expected scheme://host[:port]", origin)
+		}
+	}
+
+	if c.Database.DSN == "" {
+		add("DATABASE_URL is required")
+	} else if u, err := url.Parse(c.Database.DSN); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		add("DATABASE_URL must be a postgres:// URL")
+	}
+
+	if len(c.Auth.JWTSecret) < minSecretLength {
+		add("JWT_SECRET must be at least %d characters", minSecretLength)
+	}
+	if c.Auth.TokenTTL <= 0 {
+		add("JWT_TTL must be positive")
+	}
+
+	switch c.Storage.Driver {
+	case "local":
+		if c.Storage.Dir == "" {
+			add("STORAGE_DIR is required for local storage")
+		}
+	case "s3":
+		if c.Storage.S3.Endpoint == "" {
+			add("S3_ENDPOINT is required for s3 storage")
+		}
+		if c.Storage.S3.Bucket == "" {
+			add("S3_BUCKET is required for s3 storage")
+		}
+		if c.Storage.S3.AccessKey == "" || c.Storage.S3.SecretKey == "" {
+			add("S3_ACCESS_KEY and S3_SECRET_KEY are required for s3 storage")
+		}
+	default:
+		add("STORAGE_DRIVER must be local or s3, got %q", c.Storage.Driver)
+	}
+	if len(c.Storage.URLSecret) < minSecretLength {
+		add("FILE_URL_SECRET must be at least %d characters", minSecretLength)
+	}
+	if c.Storage.URLTTL <= 0 {
+		add("FILE_URL_TTL must be positive")
+	}
+
+	if c.Posts.EditWindow < 0 {
+		add("POST_EDIT_WINDOW must not be negative")
+	}
+	if c.Posts.TrashRetention <= 0 {
+		add("TRASH_RETENTION must be positive")
+	}
+	seen := make(map[string]bool, len(c.Posts.Reactions))
+	for _, r := range c.Posts.Reactions {
+		switch {
+		case r == "" || len(r) > maxReactionLength || strings.ContainsAny(r, ", \t\r\n"):
+			add("POST_REACTIONS: invalid reaction %q", r)
+		case seen[r]:
+			add("POST_REACTIONS: duplicate reaction %q", r)
+		}
+		seen[r] = true
+	}
+	if !seen[model.ReactionLike] {
+		add("POST_REACTIONS must include %q", model.ReactionLike)
+	}
+	if c.Comments.MaxDepth < 0 {
+		add("COMMENT_MAX_DEPTH must not be negative")
+	}
+
+	if c.Jobs.Workers < 1 {
+		add("JOB_WORKERS must be at least 1")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Способ показать значение в логах
+type redaction int
+
+const (
+	plain  redaction = iota
+	secret           // Значение скрывается полностью
+	dsn              // Скрывается только пароль в URL
+)
+
+// Связь переменной окружения с полем конфигурации
+type binding struct {
+	env       string
+	target    interface{} // *string, *int, *bool, *time.Duration или *[]string (через запятую)
+	redaction redaction
+}
+
+func (c *Config) bindings() []binding {
+	return []binding{
+		{"HTTP_ADDR", &c.HTTP.Addr, plain},
+		{"GRPC_ADDR", &c.GRPC.Addr, plain},
+		{"DATABASE_URL", &c.Database.DSN, dsn},
+		{"DATABASE_AUTO_MIGRATE", &c.Database.AutoMigrate, plain},
+		{"DATABASE_DEBUG", &c.Database.Debug, plain},
+		{"JWT_SECRET", &c.Auth.JWTSecret, secret},
+		{"JWT_TTL", &c.Auth.TokenTTL, plain},
+		{"CHAT_SERVICE_ADDR", &c.Chat.Addr, plain},
+		{"CHAT_SERVICE_TIMEOUT", &c.Chat.Timeout, plain},
+		{"CHAT_WS_ALLOWED_ORIGINS", &c.Chat.AllowedOrigins, plain},
+		{"STORAGE_DRIVER", &c.Storage.Driver, plain},
+		{"STORAGE_DIR", &c.Storage.Dir, plain},
+		{"FILE_URL_SECRET", &c.Storage.URLSecret, secret},
+		{"FILE_URL_TTL", &c.Storage.URLTTL, plain},
+		{"S3_ENDPOINT", &c.Storage.S3.Endpoint, plain},
+		{"S3_ACCESS_KEY", &c.Storage.S3.AccessKey, secret},
+		{"S3_SECRET_KEY", &c.Storage.S3.SecretKey, secret},
+		{"S3_BUCKET", &c.Storage.S3.Bucket, plain},
+		{"S3_REGION", &c.Storage.S3.Region, plain},
+		{"S3_USE_SSL", &c.Storage.S3.UseSSL, plain},
+		{"POST_EDIT_WINDOW", &c.Posts.EditWindow, plain},
+		{"TRASH_RETENTION", &c.Posts.TrashRetention, plain},
+		{"POST_REACTIONS", &c.Posts.Reactions, plain},
+		{"COMMENT_MAX_DEPTH", &c.Comments.MaxDepth, plain},
+		{"JOB_WORKERS", &c.Jobs.Workers, plain},
+		{"JOB_RECONCILE_COUNTERS", &c.Jobs.ReconcileCounters, plain},
+		{"JOB_PURGE_TRASH", &c.Jobs.PurgeTrash, plain},
+		{"JOB_PUBLISH_SCHEDULED", &c.Jobs.PublishScheduled, plain},
+	}
+}
+
+// lookupEnv возвращает значение NAME или содержимое файла из NAME_FILE
+func lookupEnv(name string) (string, bool, error) {
+	if path, ok := os.LookupEnv(name + "_FILE"); ok && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("%s_FILE: %w", name, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	}
+	value, ok := os.LookupEnv(name)
+	return value, ok, nil
+}
+
+func (c *Config) loadEnv() error {
+	var problems []string
+	for _, b := range c.bindings() {
+		raw, ok, err := lookupEnv(b.env)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := setValue(b.target, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", b.env, err))
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func setValue(target interface{}, raw string) error {
+	switch t := target.(type) {
+	case *string:
+		*t = raw
+	case *int:
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		*t = v
+	case *bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		*t = v
+	case *time.Duration:
+		v, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		*t = v
+	case *[]string:
+		*t = nil
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*t = append(*t, item)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %T", target)
+	}
+	return nil
+}
+
+// String выводит конфигурацию по переменным окружения со скрытыми секретами
+func (c *Config) String() string {
+	var sb strings.Builder
+	for _, b := range c.bindings() {
+		value := fmt.Sprint(bindingValue(b.target))
+		switch {
+		case value == "":
+		case b.redaction == secret:
+			value = "***"
+		case b.redaction == dsn:
+			if u, err := url.Parse(value); err == nil {
+				value = u.Redacted()
+			} else {
+				value = "***"
+			}
+		}
+		fmt.Fprintf(&sb, "  %s=%s\n", b.env, value)
+	}
+	return sb.String()
+}
+
+func bindingValue(target interface{}) interface{} {
+	switch t := target.(type) {
+	case *string:
+		return *t
+	case *int:
+		return *t
+	case *bool:
+		return *t
+	case *time.Duration:
+		return *t
+	case *[]string:
+		return strings.Join(*t, ",")
+	default:
+		return target
+	}
+}
@@ -0,0 +1,192 @@
+// Package counters сверяет денормализованные счётчики постов (likes_count,
+// comments_count, reposts_count, quotes_count и число реакций по типам) с таблицами
+// post_reactions, comments, reposts и цитатами в posts. Удалённые в корзину
+// комментарии и цитаты не считаются.
+// Обработчики меняют счётчики в одной транзакции с самими строками, а сверка
+// исправляет расхождения, накопленные до этого или после ручных правок в базе.
+package counters
+
+import (
+	"api-service/jobs"
+	"api-service/model"
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/uptrace/bun"
+)
+
+// JobKind — вид фоновой задачи сверки
+const JobKind = "counters.reconcile"
+
+// Сколько постов блокируется и сверяется в одной транзакции
+const batchSize = 500
+
+// Correction — исправленное значение одного счётчика
+type Correction struct {
+	PostID  int    `json:"post_id"`
+	Counter string `json:"counter"`
+	Was     int    `json:"was"`
+	Now     int    `json:"now"`
+}
+
+// Report — результат сверки
+type Report struct {
+	PostsChecked int          `json:"posts_checked"`
+	Corrections  []Correction `json:"corrections"`
+}
+
+// Строка, возвращаемая UPDATE ... RETURNING: старые и новые значения
+type reconciledRow struct {
+	ID          int `bun:"id"`
+	OldLikes    int `bun:"old_likes"`
+	Likes       int `bun:"likes"`
+	OldComments int `bun:"old_comments"`
+	Comments    int `bun:"comments"`
+	OldReposts  int `bun:"old_reposts"`
+	Reposts     int `bun:"reposts"`
+	OldQuotes   int `bun:"old_quotes"`
+	Quotes      int `bun:"quotes"`
+}
+
+// Пересчёт пачки постов. Строки постов к этому моменту заблокированы FOR UPDATE,
+// поэтому подзапросы видят все зафиксированные лайки, комментарии и репосты,
+// а новые ждут конца транзакции (вставка по внешнему ключу берёт FOR KEY SHARE).
+const reconcileQuery = `
+UPDATE posts AS p SET
+	likes_count = a.likes,
+	comments_count = a.comments,
+	reposts_count = a.reposts,
+	quotes_count = a.quotes
+FROM (
+	SELECT
+		posts.id,
+		posts.likes_count AS old_likes,
+		posts.comments_count AS old_comments,
+		posts.reposts_count AS old_reposts,
+		posts.quotes_count AS old_quotes,
+		(SELECT COUNT(*) FROM post_reactions WHERE post_reactions.post_id = posts.id) AS likes,
+		(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL) AS comments,
+		(SELECT COUNT(*) FROM reposts WHERE reposts.original_post_id = posts.id) AS reposts,
+		(SELECT COUNT(*) FROM posts AS q WHERE q.quoted_post_id = posts.id
+			AND q.status = 'published' AND q.deleted_at IS NULL) AS quotes
+	FROM posts
+	WHERE posts.id IN (?)
+) AS a
+WHERE p.id = a.id
+	AND (p.likes_count <> a.likes OR p.comments_count <> a.comments OR p.reposts_count <> a.reposts
+		OR p.quotes_count <> a.quotes)
+RETURNING p.id, a.old_likes, a.likes, a.old_comments, a.comments, a.old_reposts, a.reposts,
+	a.old_quotes, a.quotes`
+
+// Строка сверки числа реакций одного типа
+type reactionRow struct {
+	PostID int    `bun:"post_id"`
+	Type   string `bun:"type"`
+	Old    int    `bun:"old"`
+	Count  int    `bun:"count"`
+}
+
+// Пересчёт post_reaction_counts для той же пачки постов. Реакции на эти посты
+// тоже ждут блокировки строк постов. Лишние строки обнуляются, недостающие создаются
+const reconcileReactionsQuery = `
+WITH actual AS (
+	SELECT post_id, type, COUNT(*)::int AS count
+	FROM post_reactions
+	WHERE post_id IN (?0)
+	GROUP BY post_id, type
+), diff AS (
+	SELECT COALESCE(a.post_id, s.post_id) AS post_id, COALESCE(a.type, s.type) AS type,
+		COALESCE(s.count, 0) AS old, COALESCE(a.count, 0) AS count
+	FROM actual AS a
+	FULL JOIN (SELECT * FROM post_reaction_counts WHERE post_id IN (?0)) AS s
+		ON s.post_id = a.post_id AND s.type = a.type
+	WHERE COALESCE(s.count, 0) <> COALESCE(a.count, 0)
+), upserted AS (
+	INSERT INTO post_reaction_counts (post_id, type, count)
+	SELECT post_id, type, count FROM diff
+	ON CONFLICT (post_id, type) DO UPDATE SET count = EXCLUDED.count
+)
+SELECT post_id, type, old, count FROM diff`
+
+// Reconcile пересчитывает счётчики всех постов пачками по batchSize
+// и возвращает список исправлений
+func Reconcile(ctx context.Context, db *bun.DB) (*Report, error) {
+	report := &Report{Corrections: []Correction{}}
+	lastID := 0
+
+	for {
+		var ids []int
+		err := db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			err := tx.NewSelect().
+				Model((*model.Post)(nil)).
+				Column("id").
+				WhereAllWithDeleted(). // Счётчики постов в корзине должны быть верны после восстановления
+				Where("id > ?", lastID).
+				OrderExpr("id ASC").
+				Limit(batchSize).
+				For("UPDATE").
+				Scan(ctx, &ids)
+			if err != nil || len(ids) == 0 {
+				return err
+			}
+
+			var rows []reconciledRow
+			if err := tx.NewRaw(reconcileQuery, bun.In(ids)).Scan(ctx, &rows); err != nil {
+				return err
+			}
+			for _, r := range rows {
+				report.add(r.ID, "likes_count", r.OldLikes, r.Likes)
+				report.add(r.ID, "comments_count", r.OldComments, r.Comments)
+				report.add(r.ID, "reposts_count", r.OldReposts, r.Reposts)
+				report.add(r.ID, "quotes_count", r.OldQuotes, r.Quotes)
+			}
+
+			var reactions []reactionRow
+			if err := tx.NewRaw(reconcileReactionsQuery, bun.In(ids)).Scan(ctx, &reactions); err != nil {
+				return err
+			}
+			for _, r := range reactions {
+				report.add(r.PostID, "reactions."+r.Type, r.Old, r.Count)
+			}
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to reconcile counters after post %d: %w", lastID, err)
+		}
+		if len(ids) == 0 {
+			return report, nil
+		}
+
+		report.PostsChecked += len(ids)
+		lastID = ids[len(ids)-1]
+	}
+}
+
+func (r *Report) add(postID int, counter string, was, now int) {
+	if was != now {
+		r.Corrections = append(r.Corrections, Correction{PostID: postID, Counter: counter, Was: was, Now: now})
+	}
+}
+
+// Register регистрирует обработчик сверки в пуле и, если spec не пустой,
+// периодический запуск по cron-расписанию
+func Register(pool *jobs.Pool, db *bun.DB, spec string) error {
+	pool.Register(JobKind, func(ctx context.Context, job *model.Job) error {
+		report, err := Reconcile(ctx, db)
+		if err != nil {
+			return err
+		}
+		log.Printf("Сверка счётчиков: проверено постов %d, исправлено значений %d",
+			report.PostsChecked, len(report.Corrections))
+		for _, c := range report.Corrections {
+			log.Printf("Пост %d: %s %d -> %d", c.PostID, c.Counter, c.Was, c.Now)
+		}
+		return nil
+	})
+
+	if spec == "" {
+		return nil
+	}
+	return pool.Schedule(JobKind, spec, JobKind, nil)
+}
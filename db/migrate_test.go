@@ -0,0 +1,119 @@
+package db
+
+import (
+	"api-service/db/dbtest"
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoadMigrationsOrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0010_tenth.up.sql":    {Data: []byte("SELECT 10;")},
+		"migrations/0010_tenth.down.sql":  {Data: []byte("SELECT -10;")},
+		"migrations/0002_second.up.sql":   {Data: []byte("SELECT 2;")},
+		"migrations/0002_second.down.sql": {Data: []byte("SELECT -2;")},
+		"migrations/0001_first.up.sql":    {Data: []byte("SELECT 1;")},
+		"migrations/0001_first.down.sql":  {Data: []byte("SELECT -1;")},
+	}
+
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	var got []string
+	for _, m := range migrations {
+		got = append(got, m.Name)
+	}
+	if strings.Join(got, ",") != "first,second,tenth" {
+		t.Fatalf("порядок миграций %v, ожидался first,second,tenth", got)
+	}
+	if migrations[2].Up != "SELECT 10;" || migrations[2].Down != "SELECT -10;" {
+		t.Fatalf("up/down перепутаны: %+v", migrations[2])
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidSets(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"нет down": {
+			"migrations/0001_first.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"разные имена одной версии": {
+			"migrations/0001_first.up.sql":   {Data: []byte("SELECT 1;")},
+			"migrations/0001_other.down.sql": {Data: []byte("SELECT -1;")},
+		},
+		"неверное имя файла": {
+			"migrations/first.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+	for name, fsys := range cases {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("%s: ожидалась ошибка", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrationsAreComplete(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 {
+		t.Fatalf("первой должна быть 0001_baseline, получено %+v", migrations)
+	}
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version <= migrations[i-1].Version {
+			t.Fatalf("версии не возрастают: %d после %d", migrations[i].Version, migrations[i-1].Version)
+		}
+	}
+}
+
+func TestMigratorUpDown(t *testing.T) {
+	conn := dbtest.Open(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	m, err := NewMigrator(conn)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != len(m.migrations) {
+		t.Fatalf("применено %d миграций из %d", len(applied), len(m.migrations))
+	}
+	if again, err := m.Up(ctx); err != nil || len(again) != 0 {
+		t.Fatalf("повторный Up: применено %d, ошибка %v", len(again), err)
+	}
+
+	// Откат идёт от последней версии к первой, и каждая down обратима
+	rolledBack, err := m.Down(ctx, len(m.migrations))
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	for i, migration := range rolledBack {
+		want := m.migrations[len(m.migrations)-1-i]
+		if migration.Version != want.Version {
+			t.Fatalf("откат %d: версия %d, ожидалась %d", i, migration.Version, want.Version)
+		}
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, s := range statuses {
+		if !s.AppliedAt.IsZero() {
+			t.Fatalf("после полного отката %04d_%s всё ещё применена", s.Version, s.Name)
+		}
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up после отката: %v", err)
+	}
+}
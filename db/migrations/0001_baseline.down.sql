@@ -0,0 +1,17 @@
+-- Откат базовой схемы удаляет все данные
+DROP TABLE IF EXISTS
+	job_schedules,
+	jobs,
+	chat_attachments,
+	post_shares,
+	user_blocks,
+	follows,
+	reposts,
+	media,
+	post_tags,
+	comments,
+	post_likes,
+	tags,
+	posts,
+	users
+CASCADE;
@@ -0,0 +1,177 @@
+-- Базовая схема: совпадает с тем, что создавал RunMigrations.
+-- Все операции идемпотентны, поэтому миграция безопасно применяется и к пустой базе,
+-- и к уже работающей базе, созданной старым кодом.
+
+CREATE TABLE IF NOT EXISTS "users" (
+	"id" SERIAL NOT NULL,
+	"name" VARCHAR NOT NULL,
+	"email" VARCHAR NOT NULL,
+	"password" VARCHAR NOT NULL,
+	"avatar" VARCHAR,
+	"status" VARCHAR,
+	"created_at" TIMESTAMPTZ DEFAULT current_timestamp,
+	"last_seen" TIMESTAMPTZ,
+	"role" VARCHAR,
+	"bio" VARCHAR,
+	"is_private" BOOLEAN NOT NULL,
+	"dm_policy" VARCHAR NOT NULL DEFAULT 'everyone',
+	"suspended_at" TIMESTAMPTZ,
+	PRIMARY KEY ("id"),
+	UNIQUE ("email")
+);
+
+ALTER TABLE users
+	ADD COLUMN IF NOT EXISTS avatar VARCHAR(255),
+	ADD COLUMN IF NOT EXISTS status VARCHAR(50) DEFAULT 'offline',
+	ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	ADD COLUMN IF NOT EXISTS last_seen TIMESTAMP,
+	ADD COLUMN IF NOT EXISTS role VARCHAR(50) DEFAULT 'user',
+	ADD COLUMN IF NOT EXISTS bio TEXT,
+	ADD COLUMN IF NOT EXISTS is_private BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD COLUMN IF NOT EXISTS dm_policy VARCHAR(20) NOT NULL DEFAULT 'everyone',
+	ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS "posts" (
+	"id" BIGSERIAL NOT NULL,
+	"title" VARCHAR NOT NULL,
+	"content" VARCHAR,
+	"user_id" BIGINT NOT NULL,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	"likes_count" BIGINT,
+	"reposts_count" BIGINT,
+	"comments_count" BIGINT,
+	"shares_count" BIGINT,
+	PRIMARY KEY ("id")
+);
+
+ALTER TABLE posts
+	ADD COLUMN IF NOT EXISTS likes_count INT DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS comments_count INT DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS reposts_count INT DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS shares_count INT DEFAULT 0;
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'posts_user_id_fkey') THEN
+		ALTER TABLE posts
+			ADD CONSTRAINT posts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+	END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS "tags" (
+	"id" BIGSERIAL NOT NULL,
+	"name" VARCHAR NOT NULL,
+	PRIMARY KEY ("id"),
+	UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "post_likes" (
+	"id" BIGSERIAL NOT NULL,
+	"post_id" BIGINT NOT NULL,
+	"user_id" BIGINT NOT NULL,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "comments" (
+	"id" BIGSERIAL NOT NULL,
+	"post_id" BIGINT NOT NULL,
+	"user_id" BIGINT NOT NULL,
+	"content" VARCHAR NOT NULL,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "post_tags" (
+	"post_id" BIGINT,
+	"tag_id" BIGINT
+);
+
+CREATE TABLE IF NOT EXISTS "media" (
+	"id" BIGSERIAL NOT NULL,
+	"post_id" BIGINT,
+	"url" VARCHAR,
+	"type" VARCHAR,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "reposts" (
+	"id" BIGSERIAL NOT NULL,
+	"original_post_id" BIGINT NOT NULL,
+	"user_id" BIGINT NOT NULL,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "follows" (
+	"id" BIGSERIAL NOT NULL,
+	"follower_id" BIGINT NOT NULL,
+	"followee_id" BIGINT NOT NULL,
+	"accepted" BOOLEAN NOT NULL,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "follows_pair" UNIQUE ("follower_id", "followee_id")
+);
+
+CREATE TABLE IF NOT EXISTS "user_blocks" (
+	"id" BIGSERIAL NOT NULL,
+	"blocker_id" BIGINT NOT NULL,
+	"blocked_id" BIGINT NOT NULL,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "user_blocks_pair" UNIQUE ("blocker_id", "blocked_id")
+);
+
+CREATE TABLE IF NOT EXISTS "post_shares" (
+	"id" BIGSERIAL NOT NULL,
+	"post_id" BIGINT NOT NULL,
+	"user_id" BIGINT NOT NULL,
+	"chat_id" VARCHAR NOT NULL,
+	"message_id" VARCHAR,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "chat_attachments" (
+	"id" VARCHAR NOT NULL,
+	"chat_id" VARCHAR NOT NULL,
+	"uploader_id" BIGINT NOT NULL,
+	"file_name" VARCHAR NOT NULL,
+	"content_type" VARCHAR NOT NULL,
+	"size" BIGINT NOT NULL,
+	"width" BIGINT,
+	"height" BIGINT,
+	"storage_key" VARCHAR NOT NULL,
+	"thumbnail_key" VARCHAR,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	PRIMARY KEY ("id")
+);
+
+-- Очередь фоновых задач
+CREATE TABLE IF NOT EXISTS "jobs" (
+	"id" BIGSERIAL NOT NULL,
+	"kind" VARCHAR NOT NULL,
+	"payload" jsonb NOT NULL DEFAULT '{}',
+	"status" VARCHAR NOT NULL DEFAULT 'pending',
+	"attempts" BIGINT NOT NULL DEFAULT 0,
+	"max_attempts" BIGINT NOT NULL DEFAULT 5,
+	"unique_key" VARCHAR,
+	"last_error" VARCHAR,
+	"run_at" timestamptz NOT NULL DEFAULT current_timestamp,
+	"locked_at" timestamptz,
+	"locked_by" VARCHAR,
+	"created_at" timestamptz NOT NULL DEFAULT current_timestamp,
+	"finished_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "job_schedules" (
+	"name" VARCHAR NOT NULL,
+	"spec" VARCHAR NOT NULL,
+	"next_run_at" timestamptz NOT NULL,
+	PRIMARY KEY ("name")
+);
+
+CREATE INDEX IF NOT EXISTS jobs_pending_run_at_idx ON jobs (run_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS jobs_running_locked_at_idx ON jobs (locked_at) WHERE status = 'running';
+CREATE UNIQUE INDEX IF NOT EXISTS jobs_unique_key_active_idx ON jobs (unique_key)
+	WHERE status IN ('pending', 'running');
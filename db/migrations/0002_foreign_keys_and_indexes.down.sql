@@ -0,0 +1,52 @@
+DROP INDEX IF EXISTS
+	posts_user_id_idx,
+	posts_created_at_idx,
+	post_likes_user_id_idx,
+	comments_post_id_idx,
+	comments_user_id_idx,
+	comments_created_at_idx,
+	post_tags_tag_id_idx,
+	media_post_id_idx,
+	reposts_user_id_idx,
+	reposts_created_at_idx,
+	follows_followee_id_idx,
+	follows_created_at_idx,
+	user_blocks_blocked_id_idx,
+	post_shares_post_id_idx,
+	post_shares_user_id_idx,
+	post_shares_created_at_idx,
+	chat_attachments_uploader_id_idx,
+	chat_attachments_created_at_idx,
+	users_created_at_idx;
+
+ALTER TABLE chat_attachments DROP CONSTRAINT IF EXISTS chat_attachments_uploader_id_fkey;
+ALTER TABLE post_shares
+	DROP CONSTRAINT IF EXISTS post_shares_post_id_fkey,
+	DROP CONSTRAINT IF EXISTS post_shares_user_id_fkey;
+ALTER TABLE user_blocks
+	DROP CONSTRAINT IF EXISTS user_blocks_blocker_id_fkey,
+	DROP CONSTRAINT IF EXISTS user_blocks_blocked_id_fkey;
+ALTER TABLE follows
+	DROP CONSTRAINT IF EXISTS follows_follower_id_fkey,
+	DROP CONSTRAINT IF EXISTS follows_followee_id_fkey;
+ALTER TABLE reposts
+	DROP CONSTRAINT IF EXISTS reposts_original_post_id_fkey,
+	DROP CONSTRAINT IF EXISTS reposts_user_id_fkey,
+	DROP CONSTRAINT IF EXISTS reposts_pair;
+ALTER TABLE media DROP CONSTRAINT IF EXISTS media_post_id_fkey;
+ALTER TABLE post_tags
+	DROP CONSTRAINT IF EXISTS post_tags_post_id_fkey,
+	DROP CONSTRAINT IF EXISTS post_tags_tag_id_fkey,
+	DROP CONSTRAINT IF EXISTS post_tags_pair;
+ALTER TABLE comments
+	DROP CONSTRAINT IF EXISTS comments_post_id_fkey,
+	DROP CONSTRAINT IF EXISTS comments_user_id_fkey;
+ALTER TABLE post_likes
+	DROP CONSTRAINT IF EXISTS post_likes_post_id_fkey,
+	DROP CONSTRAINT IF EXISTS post_likes_user_id_fkey,
+	DROP CONSTRAINT IF EXISTS post_likes_pair;
+
+ALTER TABLE media ALTER COLUMN post_id DROP NOT NULL;
+ALTER TABLE post_tags
+	ALTER COLUMN post_id DROP NOT NULL,
+	ALTER COLUMN tag_id DROP NOT NULL;
@@ -0,0 +1,82 @@
+-- Внешние ключи с каскадным удалением, уникальность связей и индексы.
+-- Перед добавлением ограничений удаляются висячие строки и дубликаты,
+-- которые могли накопиться без них. Счётчики постов пересчитываются отдельно.
+
+-- Висячие строки
+DELETE FROM post_likes WHERE post_id NOT IN (SELECT id FROM posts) OR user_id NOT IN (SELECT id FROM users);
+DELETE FROM comments WHERE post_id NOT IN (SELECT id FROM posts) OR user_id NOT IN (SELECT id FROM users);
+DELETE FROM post_tags WHERE post_id IS NULL OR tag_id IS NULL
+	OR post_id NOT IN (SELECT id FROM posts) OR tag_id NOT IN (SELECT id FROM tags);
+DELETE FROM media WHERE post_id IS NULL OR post_id NOT IN (SELECT id FROM posts);
+DELETE FROM reposts WHERE original_post_id NOT IN (SELECT id FROM posts) OR user_id NOT IN (SELECT id FROM users);
+DELETE FROM follows WHERE follower_id NOT IN (SELECT id FROM users) OR followee_id NOT IN (SELECT id FROM users);
+DELETE FROM user_blocks WHERE blocker_id NOT IN (SELECT id FROM users) OR blocked_id NOT IN (SELECT id FROM users);
+DELETE FROM post_shares WHERE post_id NOT IN (SELECT id FROM posts) OR user_id NOT IN (SELECT id FROM users);
+DELETE FROM chat_attachments WHERE uploader_id NOT IN (SELECT id FROM users);
+
+-- Дубликаты: оставляем самую раннюю запись
+DELETE FROM post_likes a USING post_likes b
+	WHERE a.post_id = b.post_id AND a.user_id = b.user_id AND a.id > b.id;
+DELETE FROM reposts a USING reposts b
+	WHERE a.original_post_id = b.original_post_id AND a.user_id = b.user_id AND a.id > b.id;
+DELETE FROM post_tags a USING post_tags b
+	WHERE a.post_id = b.post_id AND a.tag_id = b.tag_id AND a.ctid > b.ctid;
+
+ALTER TABLE post_tags
+	ALTER COLUMN post_id SET NOT NULL,
+	ALTER COLUMN tag_id SET NOT NULL;
+ALTER TABLE media
+	ALTER COLUMN post_id SET NOT NULL;
+
+-- Уникальность
+ALTER TABLE post_likes ADD CONSTRAINT post_likes_pair UNIQUE (post_id, user_id);
+ALTER TABLE reposts ADD CONSTRAINT reposts_pair UNIQUE (original_post_id, user_id);
+ALTER TABLE post_tags ADD CONSTRAINT post_tags_pair UNIQUE (post_id, tag_id);
+
+-- Внешние ключи
+ALTER TABLE post_likes
+	ADD CONSTRAINT post_likes_post_id_fkey FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	ADD CONSTRAINT post_likes_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE comments
+	ADD CONSTRAINT comments_post_id_fkey FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	ADD CONSTRAINT comments_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE post_tags
+	ADD CONSTRAINT post_tags_post_id_fkey FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	ADD CONSTRAINT post_tags_tag_id_fkey FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE;
+ALTER TABLE media
+	ADD CONSTRAINT media_post_id_fkey FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE;
+ALTER TABLE reposts
+	ADD CONSTRAINT reposts_original_post_id_fkey FOREIGN KEY (original_post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	ADD CONSTRAINT reposts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE follows
+	ADD CONSTRAINT follows_follower_id_fkey FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+	ADD CONSTRAINT follows_followee_id_fkey FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE user_blocks
+	ADD CONSTRAINT user_blocks_blocker_id_fkey FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+	ADD CONSTRAINT user_blocks_blocked_id_fkey FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE post_shares
+	ADD CONSTRAINT post_shares_post_id_fkey FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	ADD CONSTRAINT post_shares_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE chat_attachments
+	ADD CONSTRAINT chat_attachments_uploader_id_fkey FOREIGN KEY (uploader_id) REFERENCES users(id) ON DELETE CASCADE;
+
+-- Индексы по внешним ключам (кроме покрытых уникальными ограничениями) и по created_at
+CREATE INDEX posts_user_id_idx ON posts (user_id);
+CREATE INDEX posts_created_at_idx ON posts (created_at);
+CREATE INDEX post_likes_user_id_idx ON post_likes (user_id);
+CREATE INDEX comments_post_id_idx ON comments (post_id);
+CREATE INDEX comments_user_id_idx ON comments (user_id);
+CREATE INDEX comments_created_at_idx ON comments (created_at);
+CREATE INDEX post_tags_tag_id_idx ON post_tags (tag_id);
+CREATE INDEX media_post_id_idx ON media (post_id);
+CREATE INDEX reposts_user_id_idx ON reposts (user_id);
+CREATE INDEX reposts_created_at_idx ON reposts (created_at);
+CREATE INDEX follows_followee_id_idx ON follows (followee_id);
+CREATE INDEX follows_created_at_idx ON follows (created_at);
+CREATE INDEX user_blocks_blocked_id_idx ON user_blocks (blocked_id);
+CREATE INDEX post_shares_post_id_idx ON post_shares (post_id);
+CREATE INDEX post_shares_user_id_idx ON post_shares (user_id);
+CREATE INDEX post_shares_created_at_idx ON post_shares (created_at);
+CREATE INDEX chat_attachments_uploader_id_idx ON chat_attachments (uploader_id);
+CREATE INDEX chat_attachments_created_at_idx ON chat_attachments (created_at);
+CREATE INDEX users_created_at_idx ON users (created_at);
@@ -0,0 +1,19 @@
+-- История правок постов и отметка о редактировании постов и комментариев
+ALTER TABLE posts ADD COLUMN edited_at TIMESTAMPTZ;
+ALTER TABLE comments ADD COLUMN edited_at TIMESTAMPTZ;
+
+-- Снимок поста на каждую версию: первая создаётся вместе с постом,
+-- для старых постов — при первой правке
+CREATE TABLE post_revisions (
+	id BIGSERIAL PRIMARY KEY,
+	post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	version INTEGER NOT NULL,
+	title VARCHAR NOT NULL,
+	content VARCHAR,
+	tags JSONB NOT NULL DEFAULT '[]',
+	media JSONB NOT NULL DEFAULT '[]',
+	editor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	CONSTRAINT post_revisions_version UNIQUE (post_id, version)
+);
+CREATE INDEX post_revisions_editor_id_idx ON post_revisions (editor_id);
@@ -0,0 +1,7 @@
+-- Удалённые посты и комментарии при откате удаляются окончательно
+DELETE FROM comments WHERE deleted_at IS NOT NULL;
+DELETE FROM posts WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS comments_deleted_at_idx, posts_deleted_at_idx;
+ALTER TABLE comments DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,10 @@
+-- Неопубликованные посты без статуса стали бы видны всем
+DELETE FROM posts WHERE status <> 'published';
+
+DROP INDEX IF EXISTS posts_user_id_status_idx;
+DROP INDEX IF EXISTS posts_scheduled_publish_at_idx;
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_scheduled_publish_at_check;
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_status_check;
+ALTER TABLE posts DROP COLUMN IF EXISTS published_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS publish_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS status;
@@ -0,0 +1,15 @@
+-- Черновики и отложенная публикация. Существующие посты считаются опубликованными
+-- в момент создания
+ALTER TABLE posts ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+ALTER TABLE posts ADD COLUMN publish_at TIMESTAMPTZ;
+ALTER TABLE posts ADD COLUMN published_at TIMESTAMPTZ;
+
+UPDATE posts SET published_at = created_at;
+
+ALTER TABLE posts ADD CONSTRAINT posts_status_check
+	CHECK (status IN ('draft', 'scheduled', 'published'));
+ALTER TABLE posts ADD CONSTRAINT posts_scheduled_publish_at_check
+	CHECK (status <> 'scheduled' OR publish_at IS NOT NULL);
+
+CREATE INDEX posts_scheduled_publish_at_idx ON posts (publish_at) WHERE status = 'scheduled';
+CREATE INDEX posts_user_id_status_idx ON posts (user_id, status);
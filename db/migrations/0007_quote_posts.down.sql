@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS posts_quoted_post_id_idx;
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_quote_check;
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_quoted_post_id_fkey;
+ALTER TABLE posts DROP COLUMN IF EXISTS quotes_count;
+ALTER TABLE posts DROP COLUMN IF EXISTS is_quote;
+ALTER TABLE posts DROP COLUMN IF EXISTS quoted_post_id;
@@ -0,0 +1,11 @@
+-- Цитаты: пост со ссылкой на оригинал. Если оригинал удалён окончательно,
+-- ссылка обнуляется, а is_quote сохраняет, что пост был цитатой
+ALTER TABLE posts ADD COLUMN quoted_post_id INTEGER;
+ALTER TABLE posts ADD COLUMN is_quote BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE posts ADD COLUMN quotes_count INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE posts
+	ADD CONSTRAINT posts_quoted_post_id_fkey FOREIGN KEY (quoted_post_id) REFERENCES posts(id) ON DELETE SET NULL;
+ALTER TABLE posts ADD CONSTRAINT posts_quote_check CHECK (is_quote OR quoted_post_id IS NULL);
+
+CREATE INDEX posts_quoted_post_id_idx ON posts (quoted_post_id);
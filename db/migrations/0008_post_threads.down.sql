@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS posts_conversation_id_idx;
+DROP INDEX IF EXISTS posts_in_reply_to_id_idx;
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_in_reply_to_id_fkey;
+ALTER TABLE posts DROP COLUMN IF EXISTS conversation_id;
+ALTER TABLE posts DROP COLUMN IF EXISTS in_reply_to_id;
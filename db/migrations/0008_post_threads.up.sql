@@ -0,0 +1,13 @@
+-- Ответы постами: in_reply_to_id — родительский пост, conversation_id — корень ветки.
+-- У conversation_id нет внешнего ключа, чтобы ветка не распалась, когда корень
+-- удалён окончательно
+ALTER TABLE posts ADD COLUMN in_reply_to_id INTEGER;
+ALTER TABLE posts ADD COLUMN conversation_id INTEGER;
+
+UPDATE posts SET conversation_id = id;
+
+ALTER TABLE posts
+	ADD CONSTRAINT posts_in_reply_to_id_fkey FOREIGN KEY (in_reply_to_id) REFERENCES posts(id) ON DELETE SET NULL;
+
+CREATE INDEX posts_in_reply_to_id_idx ON posts (in_reply_to_id, published_at, id);
+CREATE INDEX posts_conversation_id_idx ON posts (conversation_id);
@@ -0,0 +1,12 @@
+-- Ответы на комментарии: parent_id — родительский комментарий, depth — уровень
+-- вложенности (0 у комментариев к посту), replies_count — число прямых ответов.
+-- Когда родитель удаляется окончательно, ответы остаются и поднимаются на верхний уровень
+ALTER TABLE comments ADD COLUMN parent_id INTEGER;
+ALTER TABLE comments ADD COLUMN depth INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN replies_count INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE comments
+	ADD CONSTRAINT comments_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES comments(id) ON DELETE SET NULL;
+
+CREATE INDEX comments_post_id_parent_id_idx ON comments (post_id, parent_id, created_at);
+CREATE INDEX comments_parent_id_idx ON comments (parent_id);
@@ -0,0 +1,2 @@
+ALTER TABLE comments DROP COLUMN IF EXISTS likes_count;
+DROP TABLE IF EXISTS comment_likes;
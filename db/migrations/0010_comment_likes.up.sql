@@ -0,0 +1,12 @@
+-- Лайки комментариев и их денормализованный счётчик
+CREATE TABLE comment_likes (
+	id SERIAL PRIMARY KEY,
+	comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	CONSTRAINT comment_likes_pair UNIQUE (comment_id, user_id)
+);
+
+CREATE INDEX comment_likes_user_id_idx ON comment_likes (user_id);
+
+ALTER TABLE comments ADD COLUMN likes_count INTEGER NOT NULL DEFAULT 0;
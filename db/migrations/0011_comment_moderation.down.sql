@@ -0,0 +1,7 @@
+ALTER TABLE comments DROP CONSTRAINT IF EXISTS comments_deleted_by_fkey;
+ALTER TABLE comments DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE comments DROP COLUMN IF EXISTS hidden_at;
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_pinned_comment_id_fkey;
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_comments_policy_check;
+ALTER TABLE posts DROP COLUMN IF EXISTS pinned_comment_id;
+ALTER TABLE posts DROP COLUMN IF EXISTS comments_policy;
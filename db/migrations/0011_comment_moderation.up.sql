@@ -0,0 +1,18 @@
+-- Управление комментариями автором поста: кто может комментировать,
+-- закреплённый комментарий и скрытые комментарии
+ALTER TABLE posts
+	ADD COLUMN comments_policy TEXT NOT NULL DEFAULT 'everyone',
+	ADD COLUMN pinned_comment_id INTEGER,
+	ADD CONSTRAINT posts_comments_policy_check
+		CHECK (comments_policy IN ('everyone', 'followers', 'mentioned', 'nobody')),
+	ADD CONSTRAINT posts_pinned_comment_id_fkey
+		FOREIGN KEY (pinned_comment_id) REFERENCES comments(id) ON DELETE SET NULL;
+
+-- Комментарий может удалить и автор поста: восстанавливает тот, кто удалил
+ALTER TABLE comments
+	ADD COLUMN hidden_at TIMESTAMPTZ,
+	ADD COLUMN deleted_by INTEGER,
+	ADD CONSTRAINT comments_deleted_by_fkey
+		FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE comments SET deleted_by = user_id WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,17 @@
+-- Любая реакция снова становится лайком
+CREATE TABLE post_likes (
+	id BIGSERIAL PRIMARY KEY,
+	post_id BIGINT NOT NULL,
+	user_id BIGINT NOT NULL,
+	CONSTRAINT post_likes_pair UNIQUE (post_id, user_id),
+	CONSTRAINT post_likes_post_id_fkey FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	CONSTRAINT post_likes_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX post_likes_user_id_idx ON post_likes (user_id);
+
+INSERT INTO post_likes (post_id, user_id)
+SELECT post_id, user_id FROM post_reactions ORDER BY id;
+
+DROP TABLE IF EXISTS post_reaction_counts;
+DROP TABLE IF EXISTS post_reactions;
@@ -0,0 +1,30 @@
+-- Реакции на посты вместо лайков: одна реакция пользователя на пост.
+-- Набор типов задаётся конфигурацией (posts.reactions), поэтому в схеме он не ограничен
+CREATE TABLE post_reactions (
+	id BIGSERIAL PRIMARY KEY,
+	post_id BIGINT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	type TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	CONSTRAINT post_reactions_pair UNIQUE (post_id, user_id)
+);
+
+CREATE INDEX post_reactions_post_id_type_idx ON post_reactions (post_id, type, id);
+CREATE INDEX post_reactions_user_id_idx ON post_reactions (user_id);
+
+-- Число реакций каждого типа: читается вместе с постом без COUNT(*)
+CREATE TABLE post_reaction_counts (
+	post_id BIGINT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	type TEXT NOT NULL,
+	count INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (post_id, type)
+);
+
+-- Старые лайки становятся реакцией like; likes_count теперь считает реакции всех типов
+INSERT INTO post_reactions (post_id, user_id, type)
+SELECT post_id, user_id, 'like' FROM post_likes ORDER BY id;
+
+INSERT INTO post_reaction_counts (post_id, type, count)
+SELECT post_id, 'like', COUNT(*) FROM post_reactions GROUP BY post_id;
+
+DROP TABLE post_likes;
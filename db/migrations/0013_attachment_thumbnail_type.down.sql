@@ -0,0 +1,2 @@
+ALTER TABLE chat_attachments
+	DROP COLUMN IF EXISTS thumbnail_content_type;
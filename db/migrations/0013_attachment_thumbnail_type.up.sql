@@ -0,0 +1,8 @@
+-- Тип миниатюры хранится рядом с ключом: он зависит от формата исходного изображения
+ALTER TABLE chat_attachments
+	ADD COLUMN thumbnail_content_type VARCHAR;
+
+-- Миниатюры JPEG сохранялись в JPEG, остальные — в PNG
+UPDATE chat_attachments
+SET thumbnail_content_type = CASE WHEN content_type = 'image/jpeg' THEN 'image/jpeg' ELSE 'image/png' END
+WHERE thumbnail_key IS NOT NULL;
@@ -0,0 +1,11 @@
+-- Возвращаем счётчики к виду 0001_baseline. Нули, записанные вместо NULL,
+-- остаются: какие из них были NULL, уже неизвестно
+ALTER TABLE posts
+	ALTER COLUMN likes_count DROP NOT NULL,
+	ALTER COLUMN likes_count DROP DEFAULT,
+	ALTER COLUMN reposts_count DROP NOT NULL,
+	ALTER COLUMN reposts_count DROP DEFAULT,
+	ALTER COLUMN comments_count DROP NOT NULL,
+	ALTER COLUMN comments_count DROP DEFAULT,
+	ALTER COLUMN shares_count DROP NOT NULL,
+	ALTER COLUMN shares_count DROP DEFAULT;
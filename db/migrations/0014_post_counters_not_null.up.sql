@@ -0,0 +1,21 @@
+-- 0001_baseline создаёт счётчики постов без NOT NULL и значения по умолчанию:
+-- заполняем пропуски нулями и запрещаем NULL дальше
+UPDATE posts
+SET likes_count = COALESCE(likes_count, 0),
+	reposts_count = COALESCE(reposts_count, 0),
+	comments_count = COALESCE(comments_count, 0),
+	shares_count = COALESCE(shares_count, 0)
+WHERE likes_count IS NULL
+	OR reposts_count IS NULL
+	OR comments_count IS NULL
+	OR shares_count IS NULL;
+
+ALTER TABLE posts
+	ALTER COLUMN likes_count SET DEFAULT 0,
+	ALTER COLUMN likes_count SET NOT NULL,
+	ALTER COLUMN reposts_count SET DEFAULT 0,
+	ALTER COLUMN reposts_count SET NOT NULL,
+	ALTER COLUMN comments_count SET DEFAULT 0,
+	ALTER COLUMN comments_count SET NOT NULL,
+	ALTER COLUMN shares_count SET DEFAULT 0,
+	ALTER COLUMN shares_count SET NOT NULL;
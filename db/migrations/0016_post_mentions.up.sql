@@ -0,0 +1,22 @@
+-- Упоминания @имя сохраняются ID пользователей в момент сохранения поста:
+-- смена имени потом не даёт и не отнимает права комментировать при настройке mentioned
+CREATE TABLE post_mentions (
+	post_id BIGINT NOT NULL,
+	user_id BIGINT NOT NULL,
+	CONSTRAINT post_mentions_pkey PRIMARY KEY (post_id, user_id),
+	CONSTRAINT post_mentions_post_id_fkey FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	CONSTRAINT post_mentions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX post_mentions_user_id_idx ON post_mentions (user_id);
+
+-- Упоминания существующих постов по текущим именам; неоднозначное имя
+-- (одно на нескольких пользователей) никого не упоминает
+INSERT INTO post_mentions (post_id, user_id)
+SELECT DISTINCT p.id, u.id
+FROM posts AS p
+CROSS JOIN LATERAL regexp_matches(
+	lower(coalesce(p.title, '') || ' ' || coalesce(p.content, '')), '@([[:alnum:]_]+)', 'g'
+) AS m(name)
+JOIN users AS u ON lower(u.name) = m.name[1]
+WHERE lower(u.name) IN (SELECT lower(name) FROM users GROUP BY lower(name) HAVING COUNT(*) = 1);
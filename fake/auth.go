@@ -0,0 +1,163 @@
+package fake
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	authpb "api-service/proto/auth-service/proto"
+)
+
+const maxBatchUsers = 500
+
+type tokenInfo struct {
+	userID   int32
+	username string
+}
+
+type interactionKey struct {
+	actor, target int32
+	action        authpb.InteractionAction
+}
+
+// AuthServer — реализация authpb.AuthServiceServer в памяти.
+// Пользователи и токены задаются заранее; по умолчанию любое взаимодействие разрешено.
+type AuthServer struct {
+	authpb.UnimplementedAuthServiceServer
+
+	mu     sync.Mutex
+	users  map[int32]*authpb.PublicUser
+	tokens map[string]tokenInfo
+	denied map[interactionKey]string
+}
+
+// NewAuthServer создаёт сервер без пользователей
+func NewAuthServer() *AuthServer {
+	return &AuthServer{
+		users:  make(map[int32]*authpb.PublicUser),
+		tokens: make(map[string]tokenInfo),
+		denied: make(map[interactionKey]string),
+	}
+}
+
+// AddUser добавляет или заменяет пользователя
+func (s *AuthServer) AddUser(user *authpb.PublicUser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.GetId()] = proto.Clone(user).(*authpb.PublicUser)
+}
+
+// RemoveUser удаляет пользователя; его токены перестают быть валидными
+func (s *AuthServer) RemoveUser(userID int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, userID)
+}
+
+// AddToken регистрирует токен пользователя для ValidateToken
+func (s *AuthServer) AddToken(token string, userID int32, username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token] = tokenInfo{userID: userID, username: username}
+}
+
+// Deny запрещает actorID действие action по отношению к targetID с причиной reason
+func (s *AuthServer) Deny(actorID, targetID int32, action authpb.InteractionAction, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.denied[interactionKey{actorID, targetID, action}] = reason
+}
+
+// Allow отменяет запрет, заданный через Deny
+func (s *AuthServer) Allow(actorID, targetID int32, action authpb.InteractionAction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.denied, interactionKey{actorID, targetID, action})
+}
+
+func (s *AuthServer) ValidateToken(ctx context.Context, req *authpb.ValidateTokenRequest) (*authpb.ValidateTokenResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, ok := s.tokens[req.GetToken()]
+	if !ok {
+		return &authpb.ValidateTokenResponse{Valid: false}, nil
+	}
+	if _, exists := s.users[info.userID]; !exists {
+		return &authpb.ValidateTokenResponse{Valid: false}, nil
+	}
+	return &authpb.ValidateTokenResponse{Valid: true, UserId: info.userID, Username: info.username}, nil
+}
+
+func (s *AuthServer) GetUser(ctx context.Context, req *authpb.GetUserRequest) (*authpb.GetUserResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[req.GetUserId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return &authpb.GetUserResponse{User: proto.Clone(user).(*authpb.PublicUser)}, nil
+}
+
+func (s *AuthServer) BatchGetUsers(ctx context.Context, req *authpb.BatchGetUsersRequest) (*authpb.BatchGetUsersResponse, error) {
+	if len(req.GetUserIds()) > maxBatchUsers {
+		return nil, status.Errorf(codes.InvalidArgument, "too many user ids: max %d", maxBatchUsers)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &authpb.BatchGetUsersResponse{}
+	var seen []int32
+	for _, id := range req.GetUserIds() {
+		if slices.Contains(seen, id) {
+			continue
+		}
+		seen = append(seen, id)
+		if user, ok := s.users[id]; ok {
+			resp.Users = append(resp.Users, proto.Clone(user).(*authpb.PublicUser))
+		} else {
+			resp.MissingIds = append(resp.MissingIds, id)
+		}
+	}
+	return resp, nil
+}
+
+func (s *AuthServer) CheckUsersExist(ctx context.Context, req *authpb.CheckUsersExistRequest) (*authpb.CheckUsersExistResponse, error) {
+	if len(req.GetUserIds()) > maxBatchUsers {
+		return nil, status.Errorf(codes.InvalidArgument, "too many user ids: max %d", maxBatchUsers)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &authpb.CheckUsersExistResponse{Exists: make(map[int32]bool), AllExist: true}
+	for _, id := range req.GetUserIds() {
+		_, ok := s.users[id]
+		resp.Exists[id] = ok
+		resp.AllExist = resp.AllExist && ok
+	}
+	return resp, nil
+}
+
+func (s *AuthServer) CanInteract(ctx context.Context, req *authpb.CanInteractRequest) (*authpb.CanInteractResponse, error) {
+	if req.GetAction() == authpb.InteractionAction_INTERACTION_ACTION_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "unsupported action")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[req.GetTargetId()]; !ok {
+		return &authpb.CanInteractResponse{Reason: "target_not_found"}, nil
+	}
+	if reason, ok := s.denied[interactionKey{req.GetActorId(), req.GetTargetId(), req.GetAction()}]; ok {
+		return &authpb.CanInteractResponse{Reason: reason}, nil
+	}
+	return &authpb.CanInteractResponse{Allowed: true}, nil
+}
@@ -0,0 +1,676 @@
+package fake
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	chatpb "api-service/proto/chat-service/proto"
+)
+
+const (
+	defaultPageSize  = 50
+	subscriberBuffer = 64
+)
+
+type storedMessage struct {
+	seq int64
+	msg *chatpb.Message
+}
+
+type chatState struct {
+	chat     *chatpb.Chat
+	messages []*storedMessage // По возрастанию seq
+	events   []*chatpb.ChatEvent
+	nextSeq  int64
+	nextEvt  int64
+	subs     map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	userID string
+	events chan *chatpb.ChatEvent
+	closed bool
+}
+
+// ChatServer — реализация chatpb.ChatServiceServer в памяти.
+// Повторяет наблюдаемое поведение chat-service: коды ошибок, постраничную выдачу,
+// счётчики непрочитанных и поток событий с докачкой по after_event_id.
+type ChatServer struct {
+	chatpb.UnimplementedChatServiceServer
+
+	mu       sync.Mutex
+	chats    map[string]*chatState
+	messages map[string]*storedMessage
+	nextChat int64
+	nextMsg  int64
+	errors   map[string]error
+	now      func() time.Time
+}
+
+// NewChatServer создаёт пустой сервер
+func NewChatServer() *ChatServer {
+	return &ChatServer{
+		chats:    make(map[string]*chatState),
+		messages: make(map[string]*storedMessage),
+		errors:   make(map[string]error),
+		now:      time.Now,
+	}
+}
+
+// Fail заставляет метод (например "GetChat") возвращать err, пока не вызван ClearFailures
+func (s *ChatServer) Fail(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[method] = err
+}
+
+// ClearFailures отменяет все ошибки, заданные через Fail
+func (s *ChatServer) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = make(map[string]error)
+}
+
+// DisconnectSubscribers обрывает все потоки событий, как при перезапуске chat-service
+func (s *ChatServer) DisconnectSubscribers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, state := range s.chats {
+		for sub := range state.subs {
+			s.dropLocked(state, sub)
+		}
+	}
+}
+
+func (s *ChatServer) failure(method string) error {
+	return s.errors[method]
+}
+
+func (s *ChatServer) chatLocked(chatID string) (*chatState, error) {
+	state, ok := s.chats[chatID]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "chat not found")
+	}
+	return state, nil
+}
+
+func (s *ChatServer) messageLocked(messageID string) (*storedMessage, *chatState, error) {
+	stored, ok := s.messages[messageID]
+	if !ok {
+		return nil, nil, status.Error(codes.NotFound, "message not found")
+	}
+	return stored, s.chats[stored.msg.GetChatId()], nil
+}
+
+func (s *ChatServer) dropLocked(state *chatState, sub *subscriber) {
+	if !sub.closed {
+		sub.closed = true
+		close(sub.events)
+	}
+	delete(state.subs, sub)
+}
+
+// publishLocked рассылает событие подписчикам; события набора текста в историю не попадают
+func (s *ChatServer) publishLocked(state *chatState, event *chatpb.ChatEvent) {
+	state.nextEvt++
+	event.Id = strconv.FormatInt(state.nextEvt, 10)
+	event.ChatId = state.chat.GetId()
+	event.CreatedAt = timestamppb.New(s.now())
+	if event.GetType() != chatpb.ChatEventType_CHAT_EVENT_TYPE_TYPING {
+		state.events = append(state.events, event)
+	}
+
+	for sub := range state.subs {
+		select {
+		case sub.events <- proto.Clone(event).(*chatpb.ChatEvent):
+		default:
+			// Медленный подписчик отключается и переподключится с последнего события
+			s.dropLocked(state, sub)
+		}
+	}
+}
+
+func cloneChat(c *chatpb.Chat) *chatpb.Chat          { return proto.Clone(c).(*chatpb.Chat) }
+func cloneMessage(m *chatpb.Message) *chatpb.Message { return proto.Clone(m).(*chatpb.Message) }
+
+func (s *ChatServer) CreateChat(ctx context.Context, req *chatpb.CreateChatRequest) (*chatpb.ChatResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("CreateChat"); err != nil {
+		return nil, err
+	}
+	if req.GetCreatorId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "creator_id is required")
+	}
+
+	participants := []string{req.GetCreatorId()}
+	for _, p := range req.GetParticipants() {
+		if p != "" && !slices.Contains(participants, p) {
+			participants = append(participants, p)
+		}
+	}
+
+	s.nextChat++
+	chat := &chatpb.Chat{
+		Id:           "chat-" + strconv.FormatInt(s.nextChat, 10),
+		Name:         req.GetName(),
+		Description:  req.GetDescription(),
+		Participants: participants,
+		CreatorId:    req.GetCreatorId(),
+		IsGroup:      req.GetIsGroup(),
+		CreatedAt:    timestamppb.New(s.now()),
+	}
+	s.chats[chat.Id] = &chatState{chat: chat, subs: make(map[*subscriber]struct{})}
+
+	return &chatpb.ChatResponse{Chat: cloneChat(chat)}, nil
+}
+
+func (s *ChatServer) GetChat(ctx context.Context, req *chatpb.GetChatRequest) (*chatpb.ChatResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("GetChat"); err != nil {
+		return nil, err
+	}
+
+	state, err := s.chatLocked(req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+	return &chatpb.ChatResponse{Chat: cloneChat(state.chat)}, nil
+}
+
+func (s *ChatServer) UpdateChat(ctx context.Context, req *chatpb.UpdateChatRequest) (*chatpb.ChatResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("UpdateChat"); err != nil {
+		return nil, err
+	}
+
+	state, err := s.chatLocked(req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+	// Пустые поля не меняются
+	if req.GetName() != "" {
+		state.chat.Name = req.GetName()
+	}
+	if req.GetDescription() != "" {
+		state.chat.Description = req.GetDescription()
+	}
+	if req.GetAvatarUrl() != "" {
+		state.chat.AvatarUrl = req.GetAvatarUrl()
+	}
+	return &chatpb.ChatResponse{Chat: cloneChat(state.chat)}, nil
+}
+
+func (s *ChatServer) DeleteChat(ctx context.Context, req *chatpb.DeleteChatRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("DeleteChat"); err != nil {
+		return nil, err
+	}
+
+	state, err := s.chatLocked(req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range state.messages {
+		delete(s.messages, m.msg.GetId())
+	}
+	for sub := range state.subs {
+		s.dropLocked(state, sub)
+	}
+	delete(s.chats, req.GetChatId())
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ChatServer) ListUserChats(ctx context.Context, req *chatpb.ListUserChatsRequest) (*chatpb.ListChatsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("ListUserChats"); err != nil {
+		return nil, err
+	}
+
+	resp := &chatpb.ListChatsResponse{}
+	for _, state := range s.chats {
+		if !slices.Contains(state.chat.GetParticipants(), req.GetUserId()) {
+			continue
+		}
+		chat := cloneChat(state.chat)
+		if n := len(state.messages); n > 0 {
+			chat.LastMessage = cloneMessage(state.messages[n-1].msg)
+		}
+		for _, m := range state.messages {
+			if m.msg.GetSenderId() != req.GetUserId() && !slices.Contains(m.msg.GetReadBy(), req.GetUserId()) {
+				chat.UnreadCount++
+			}
+		}
+		resp.TotalUnread += chat.UnreadCount
+		resp.Chats = append(resp.Chats, chat)
+	}
+
+	// Порядок map случаен — сортируем по времени создания, как это делает хранилище
+	slices.SortFunc(resp.Chats, func(a, b *chatpb.Chat) int {
+		return a.GetCreatedAt().AsTime().Compare(b.GetCreatedAt().AsTime())
+	})
+	return resp, nil
+}
+
+func (s *ChatServer) SendMessage(ctx context.Context, req *chatpb.SendMessageRequest) (*chatpb.MessageResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("SendMessage"); err != nil {
+		return nil, err
+	}
+
+	state, err := s.chatLocked(req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(state.chat.GetParticipants(), req.GetSenderId()) {
+		return nil, status.Error(codes.PermissionDenied, "sender is not a participant")
+	}
+	if req.GetContent() == "" && len(req.GetFileUrls()) == 0 && len(req.GetAttachments()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "message is empty")
+	}
+
+	s.nextMsg++
+	state.nextSeq++
+	msg := &chatpb.Message{
+		Id:          "msg-" + strconv.FormatInt(s.nextMsg, 10),
+		ChatId:      req.GetChatId(),
+		SenderId:    req.GetSenderId(),
+		Content:     req.GetContent(),
+		FileUrls:    slices.Clone(req.GetFileUrls()),
+		CreatedAt:   timestamppb.New(s.now()),
+		Reactions:   map[string]string{},
+		Attachments: cloneAttachments(req.GetAttachments()),
+	}
+	stored := &storedMessage{seq: state.nextSeq, msg: msg}
+	state.messages = append(state.messages, stored)
+	s.messages[msg.Id] = stored
+
+	s.publishLocked(state, &chatpb.ChatEvent{
+		Type:    chatpb.ChatEventType_CHAT_EVENT_TYPE_MESSAGE_CREATED,
+		UserId:  req.GetSenderId(),
+		Message: cloneMessage(msg),
+	})
+	return &chatpb.MessageResponse{Message: cloneMessage(msg)}, nil
+}
+
+func cloneAttachments(in []*chatpb.Attachment) []*chatpb.Attachment {
+	out := make([]*chatpb.Attachment, 0, len(in))
+	for _, a := range in {
+		out = append(out, proto.Clone(a).(*chatpb.Attachment))
+	}
+	return out
+}
+
+func (s *ChatServer) EditMessage(ctx context.Context, req *chatpb.EditMessageRequest) (*chatpb.MessageResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("EditMessage"); err != nil {
+		return nil, err
+	}
+
+	stored, state, err := s.messageLocked(req.GetMessageId())
+	if err != nil {
+		return nil, err
+	}
+	stored.msg.Content = req.GetContent()
+	stored.msg.UpdatedAt = timestamppb.New(s.now())
+
+	s.publishLocked(state, &chatpb.ChatEvent{
+		Type:    chatpb.ChatEventType_CHAT_EVENT_TYPE_MESSAGE_EDITED,
+		UserId:  stored.msg.GetSenderId(),
+		Message: cloneMessage(stored.msg),
+	})
+	return &chatpb.MessageResponse{Message: cloneMessage(stored.msg)}, nil
+}
+
+func (s *ChatServer) DeleteMessage(ctx context.Context, req *chatpb.DeleteMessageRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("DeleteMessage"); err != nil {
+		return nil, err
+	}
+
+	stored, state, err := s.messageLocked(req.GetMessageId())
+	if err != nil {
+		return nil, err
+	}
+	delete(s.messages, req.GetMessageId())
+	state.messages = slices.DeleteFunc(state.messages, func(m *storedMessage) bool { return m == stored })
+
+	s.publishLocked(state, &chatpb.ChatEvent{
+		Type:      chatpb.ChatEventType_CHAT_EVENT_TYPE_MESSAGE_DELETED,
+		UserId:    stored.msg.GetSenderId(),
+		MessageId: req.GetMessageId(),
+	})
+	return &emptypb.Empty{}, nil
+}
+
+// GetMessages отдаёт страницу истории от старых к новым.
+// Без курсора — последние page_size сообщений; before_id листает назад, after_id — вперёд.
+func (s *ChatServer) GetMessages(ctx context.Context, req *chatpb.GetMessagesRequest) (*chatpb.ListMessagesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("GetMessages"); err != nil {
+		return nil, err
+	}
+
+	state, err := s.chatLocked(req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetBeforeId() != "" && req.GetAfterId() != "" {
+		return nil, status.Error(codes.InvalidArgument, "before_id and after_id are mutually exclusive")
+	}
+
+	cursorSeq := func(id string) (int64, error) {
+		stored, ok := s.messages[id]
+		if !ok || stored.msg.GetChatId() != req.GetChatId() {
+			return 0, status.Error(codes.InvalidArgument, "unknown cursor")
+		}
+		return stored.seq, nil
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	query := strings.ToLower(req.GetQuery())
+
+	var candidates []*storedMessage
+	switch {
+	case req.GetAfterId() != "":
+		after, err := cursorSeq(req.GetAfterId())
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range state.messages {
+			if m.seq > after && strings.Contains(strings.ToLower(m.msg.GetContent()), query) {
+				candidates = append(candidates, m)
+			}
+		}
+	default:
+		before := int64(-1)
+		if req.GetBeforeId() != "" {
+			if before, err = cursorSeq(req.GetBeforeId()); err != nil {
+				return nil, err
+			}
+		}
+		for _, m := range state.messages {
+			if (before < 0 || m.seq < before) && strings.Contains(strings.ToLower(m.msg.GetContent()), query) {
+				candidates = append(candidates, m)
+			}
+		}
+	}
+
+	resp := &chatpb.ListMessagesResponse{}
+	var page []*storedMessage
+	if req.GetAfterId() != "" {
+		page = candidates[:min(pageSize, len(candidates))]
+		resp.HasMore = len(candidates) > pageSize
+		if resp.HasMore {
+			resp.NextCursor = page[len(page)-1].msg.GetId()
+		}
+	} else {
+		page = candidates[max(0, len(candidates)-pageSize):]
+		resp.HasMore = len(candidates) > pageSize
+		if resp.HasMore {
+			resp.NextCursor = page[0].msg.GetId()
+		}
+	}
+
+	for _, m := range page {
+		resp.Messages = append(resp.Messages, cloneMessage(m.msg))
+	}
+	return resp, nil
+}
+
+func (s *ChatServer) GetMessage(ctx context.Context, req *chatpb.GetMessageRequest) (*chatpb.MessageResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("GetMessage"); err != nil {
+		return nil, err
+	}
+
+	stored, ok := s.messages[req.GetMessageId()]
+	if !ok || stored.msg.GetChatId() != req.GetChatId() {
+		return nil, status.Error(codes.NotFound, "message not found")
+	}
+	return &chatpb.MessageResponse{Message: cloneMessage(stored.msg)}, nil
+}
+
+func (s *ChatServer) AddParticipant(ctx context.Context, req *chatpb.AddParticipantRequest) (*chatpb.ChatResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("AddParticipant"); err != nil {
+		return nil, err
+	}
+
+	state, err := s.chatLocked(req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(state.chat.GetParticipants(), req.GetUserId()) {
+		return nil, status.Error(codes.AlreadyExists, "user is already a participant")
+	}
+	state.chat.Participants = append(state.chat.Participants, req.GetUserId())
+	return &chatpb.ChatResponse{Chat: cloneChat(state.chat)}, nil
+}
+
+func (s *ChatServer) RemoveParticipant(ctx context.Context, req *chatpb.RemoveParticipantRequest) (*chatpb.ChatResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("RemoveParticipant"); err != nil {
+		return nil, err
+	}
+
+	state, err := s.chatLocked(req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(state.chat.GetParticipants(), req.GetUserId()) {
+		return nil, status.Error(codes.NotFound, "participant not found")
+	}
+	state.chat.Participants = slices.DeleteFunc(state.chat.Participants, func(p string) bool { return p == req.GetUserId() })
+
+	// Удалённый участник теряет доступ и к потоку событий
+	for sub := range state.subs {
+		if sub.userID == req.GetUserId() {
+			s.dropLocked(state, sub)
+		}
+	}
+	return &chatpb.ChatResponse{Chat: cloneChat(state.chat)}, nil
+}
+
+func (s *ChatServer) ListChatParticipants(ctx context.Context, req *chatpb.ListChatParticipantsRequest) (*chatpb.ListParticipantsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("ListChatParticipants"); err != nil {
+		return nil, err
+	}
+
+	state, err := s.chatLocked(req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+	return &chatpb.ListParticipantsResponse{Participants: slices.Clone(state.chat.GetParticipants())}, nil
+}
+
+func (s *ChatServer) SetMessageReaction(ctx context.Context, req *chatpb.SetMessageReactionRequest) (*chatpb.MessageResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("SetMessageReaction"); err != nil {
+		return nil, err
+	}
+
+	stored, state, err := s.messageLocked(req.GetMessageId())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetReaction() == "" {
+		return nil, status.Error(codes.InvalidArgument, "reaction is required")
+	}
+	if stored.msg.Reactions == nil {
+		stored.msg.Reactions = map[string]string{}
+	}
+	stored.msg.Reactions[req.GetUserId()] = req.GetReaction()
+
+	s.publishLocked(state, &chatpb.ChatEvent{
+		Type:     chatpb.ChatEventType_CHAT_EVENT_TYPE_REACTION_SET,
+		UserId:   req.GetUserId(),
+		Message:  cloneMessage(stored.msg),
+		Reaction: req.GetReaction(),
+	})
+	return &chatpb.MessageResponse{Message: cloneMessage(stored.msg)}, nil
+}
+
+func (s *ChatServer) RemoveMessageReaction(ctx context.Context, req *chatpb.RemoveMessageReactionRequest) (*chatpb.MessageResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("RemoveMessageReaction"); err != nil {
+		return nil, err
+	}
+
+	stored, state, err := s.messageLocked(req.GetMessageId())
+	if err != nil {
+		return nil, err
+	}
+	delete(stored.msg.Reactions, req.GetUserId())
+
+	s.publishLocked(state, &chatpb.ChatEvent{
+		Type:    chatpb.ChatEventType_CHAT_EVENT_TYPE_REACTION_REMOVED,
+		UserId:  req.GetUserId(),
+		Message: cloneMessage(stored.msg),
+	})
+	return &chatpb.MessageResponse{Message: cloneMessage(stored.msg)}, nil
+}
+
+func (s *ChatServer) MarkMessageAsRead(ctx context.Context, req *chatpb.MarkMessageAsReadRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("MarkMessageAsRead"); err != nil {
+		return nil, err
+	}
+
+	stored, state, err := s.messageLocked(req.GetMessageId())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetChatId() != "" && stored.msg.GetChatId() != req.GetChatId() {
+		return nil, status.Error(codes.NotFound, "message not found")
+	}
+	// Повторное прочтение ничего не меняет
+	if slices.Contains(stored.msg.GetReadBy(), req.GetUserId()) {
+		return &emptypb.Empty{}, nil
+	}
+	stored.msg.ReadBy = append(stored.msg.ReadBy, req.GetUserId())
+
+	s.publishLocked(state, &chatpb.ChatEvent{
+		Type:      chatpb.ChatEventType_CHAT_EVENT_TYPE_MESSAGE_READ,
+		UserId:    req.GetUserId(),
+		MessageId: req.GetMessageId(),
+	})
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ChatServer) SendTyping(ctx context.Context, req *chatpb.SendTypingRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failure("SendTyping"); err != nil {
+		return nil, err
+	}
+
+	state, err := s.chatLocked(req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(state.chat.GetParticipants(), req.GetUserId()) {
+		return nil, status.Error(codes.PermissionDenied, "user is not a participant")
+	}
+
+	s.publishLocked(state, &chatpb.ChatEvent{
+		Type:   chatpb.ChatEventType_CHAT_EVENT_TYPE_TYPING,
+		UserId: req.GetUserId(),
+	})
+	return &emptypb.Empty{}, nil
+}
+
+// SubscribeChatEvents сначала отдаёт события после after_event_id, затем новые — до отмены контекста
+func (s *ChatServer) SubscribeChatEvents(req *chatpb.SubscribeChatEventsRequest, stream chatpb.ChatService_SubscribeChatEventsServer) error {
+	s.mu.Lock()
+	if err := s.failure("SubscribeChatEvents"); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	state, err := s.chatLocked(req.GetChatId())
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if !slices.Contains(state.chat.GetParticipants(), req.GetUserId()) {
+		s.mu.Unlock()
+		return status.Error(codes.PermissionDenied, "user is not a participant")
+	}
+
+	var after int64
+	if req.GetAfterEventId() != "" {
+		if after, err = strconv.ParseInt(req.GetAfterEventId(), 10, 64); err != nil {
+			s.mu.Unlock()
+			return status.Error(codes.InvalidArgument, "invalid after_event_id")
+		}
+	}
+
+	var backlog []*chatpb.ChatEvent
+	if req.GetAfterEventId() != "" {
+		for _, e := range state.events {
+			if id, _ := strconv.ParseInt(e.GetId(), 10, 64); id > after {
+				backlog = append(backlog, proto.Clone(e).(*chatpb.ChatEvent))
+			}
+		}
+	}
+
+	sub := &subscriber{userID: req.GetUserId(), events: make(chan *chatpb.ChatEvent, subscriberBuffer)}
+	state.subs[sub] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.dropLocked(state, sub)
+		s.mu.Unlock()
+	}()
+
+	for _, e := range backlog {
+		if err := stream.Send(e); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case e, ok := <-sub.events:
+			if !ok {
+				return status.Error(codes.Unavailable, "subscription closed")
+			}
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
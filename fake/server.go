@@ -0,0 +1,81 @@
+// Package fake содержит реализации ChatService и AuthService в памяти,
+// поднимаемые поверх bufconn. Они позволяют проверять обработчики и шлюз к chat-service
+// целиком, без Docker и сети.
+package fake
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	authpb "api-service/proto/auth-service/proto"
+	chatpb "api-service/proto/chat-service/proto"
+)
+
+const bufSize = 1 << 20
+
+// Адрес для grpc.NewClient: passthrough не пытается резолвить имя через DNS
+const Target = "passthrough:///bufnet"
+
+// Server — gRPC-сервер с фейковыми ChatService, AuthService и протоколом health
+type Server struct {
+	Chat   *ChatServer
+	Auth   *AuthServer
+	Health *health.Server
+
+	listener *bufconn.Listener
+	grpc     *grpc.Server
+}
+
+// Start запускает сервер в памяти
+func Start() *Server {
+	s := &Server{
+		Chat:     NewChatServer(),
+		Auth:     NewAuthServer(),
+		Health:   health.NewServer(),
+		listener: bufconn.Listen(bufSize),
+		grpc:     grpc.NewServer(),
+	}
+
+	chatpb.RegisterChatServiceServer(s.grpc, s.Chat)
+	authpb.RegisterAuthServiceServer(s.grpc, s.Auth)
+	healthpb.RegisterHealthServer(s.grpc, s.Health)
+	s.Health.SetServingStatus(chatpb.ChatService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	s.Health.SetServingStatus(authpb.AuthService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	go s.grpc.Serve(s.listener)
+	return s
+}
+
+// DialOption подключает клиента к серверу в памяти вместо сети
+func (s *Server) DialOption() grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return s.listener.DialContext(ctx)
+	})
+}
+
+// Conn создаёт соединение с сервером; закрывает его вызывающий
+func (s *Server) Conn() (*grpc.ClientConn, error) {
+	return grpc.NewClient(Target, s.DialOption(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+// SetChatServing переключает статус ChatService в протоколе health, имитируя падение сервиса
+func (s *Server) SetChatServing(serving bool) {
+	st := healthpb.HealthCheckResponse_SERVING
+	if !serving {
+		st = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.Health.SetServingStatus(chatpb.ChatService_ServiceDesc.ServiceName, st)
+}
+
+// Close останавливает сервер и обрывает открытые потоки
+func (s *Server) Close() {
+	s.Health.Shutdown()
+	s.grpc.Stop()
+	s.listener.Close()
+}
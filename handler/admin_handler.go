@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"api-service/counters"
+	"api-service/model"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Пересчёт счётчиков лайков, комментариев и репостов по фактическим данным.
+// Возвращает список исправленных значений
+func (h *PostHandler) ReconcileCounters(c echo.Context) error {
+	report, err := counters.Reconcile(c.Request().Context(), h.DB)
+	if err != nil {
+		log.Printf("Ошибка сверки счётчиков: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка сверки счётчиков"})
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// Блокировка пользователя администрацией. Заблокированному нельзя писать и
+// добавлять его в чаты, а сервисы через AuthService видят его как несуществующего
+func (h *UserHandler) SuspendUser(c echo.Context) error {
+	return h.setSuspended(c, true)
+}
+
+// Снятие блокировки администрацией
+func (h *UserHandler) UnsuspendUser(c echo.Context) error {
+	return h.setSuspended(c, false)
+}
+
+func (h *UserHandler) setSuspended(c echo.Context, suspended bool) error {
+	targetID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID пользователя"})
+	}
+	if suspended && targetID == c.Get("user_id").(int) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Нельзя заблокировать себя"})
+	}
+
+	query := h.DB.NewUpdate().Model((*model.User)(nil)).Where("id = ?", targetID)
+	if suspended {
+		// Повторная блокировка не сдвигает время первой
+		query = query.Set("suspended_at = COALESCE(suspended_at, ?)", time.Now())
+	} else {
+		query = query.Set("suspended_at = NULL")
+	}
+	res, err := query.Exec(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка обновления пользователя"})
+	}
+	if updated, _ := res.RowsAffected(); updated == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Пользователь не найден"})
+	}
+	h.invalidateUser(targetID)
+
+	if suspended {
+		return c.JSON(http.StatusOK, map[string]string{"message": "Пользователь заблокирован"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Пользователь разблокирован"})
+}
@@ -0,0 +1,376 @@
+package handler
+
+import (
+	"api-service/model"
+	"api-service/storage"
+	"api-service/utils"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+
+	chatpb "api-service/proto/chat-service/proto"
+)
+
+const (
+	maxAttachmentSize     = 25 << 20 // Общий предел размера вложения
+	maxImageSize          = 10 << 20
+	thumbnailMaxSide      = 320
+	maxAttachmentNameSize = 255
+)
+
+// Разрешённые типы вложений (по содержимому файла) и их максимальный размер
+var attachmentTypes = map[string]int64{
+	"image/jpeg":      maxImageSize,
+	"image/png":       maxImageSize,
+	"image/gif":       maxImageSize,
+	"image/webp":      maxImageSize,
+	"video/mp4":       maxAttachmentSize,
+	"video/webm":      maxAttachmentSize,
+	"audio/mpeg":      maxAttachmentSize,
+	"audio/ogg":       maxAttachmentSize,
+	"application/pdf": maxAttachmentSize,
+	"application/zip": maxAttachmentSize,
+	"text/plain":      1 << 20,
+}
+
+// Вложение в ответе: file_url — постоянная ссылка для file_urls сообщения,
+// url и thumbnail_url — подписанные ссылки на скачивание с ограниченным сроком
+type attachmentResponse struct {
+	*model.ChatAttachment
+	FileURL      string    `json:"file_url"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Постоянная ссылка на вложение; её передают в file_urls при отправке сообщения
+func attachmentFileURL(att *model.ChatAttachment) string {
+	return fmt.Sprintf("/chats/%s/attachments/%s", att.ChatID, att.ID)
+}
+
+// Ресурс, к которому привязана подпись ссылки
+func attachmentResource(id, variant string) string {
+	return "chat-attachment/" + id + "/" + variant
+}
+
+func (h *UserHandler) signedAttachmentURL(id, variant string, userID int) (string, time.Time) {
+	expires, sig := h.URLSigner.Sign(attachmentResource(id, variant), userID)
+	q := url.Values{}
+	q.Set("variant", variant)
+	q.Set("uid", strconv.Itoa(userID))
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("sig", sig)
+	return "/files/chat/" + id + "?" + q.Encode(), time.Unix(expires, 0).UTC()
+}
+
+func (h *UserHandler) attachmentResponse(att *model.ChatAttachment, userID int) attachmentResponse {
+	resp := attachmentResponse{ChatAttachment: att, FileURL: attachmentFileURL(att)}
+	resp.URL, resp.ExpiresAt = h.signedAttachmentURL(att.ID, "original", userID)
+	if att.ThumbnailKey != "" {
+		resp.ThumbnailURL, _ = h.signedAttachmentURL(att.ID, "thumbnail", userID)
+	}
+	return resp
+}
+
+func newAttachmentID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Имя файла без пути и управляющих символов, не длиннее maxAttachmentNameSize байт
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	for len(name) > maxAttachmentNameSize {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	return name
+}
+
+// Загрузка вложения в чат (multipart/form-data, поле file)
+func (h *UserHandler) UploadChatAttachment(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	// Запас на заголовки multipart
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, maxAttachmentSize+64<<10)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "Файл слишком большой"})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Файл не передан"})
+	}
+	if fileHeader.Size == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Пустой файл"})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Не удалось прочитать файл"})
+	}
+	defer file.Close()
+
+	// Тип определяем по содержимому, заявленному клиентом типу не доверяем
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Не удалось прочитать файл"})
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	// DetectContentType отдаёт контейнер Ogg как application/ogg; принимаем его как аудио
+	if contentType == "application/ogg" {
+		contentType = "audio/ogg"
+	}
+	limit, allowed := attachmentTypes[contentType]
+	if !allowed {
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]string{"error": "Недопустимый тип файла: " + contentType})
+	}
+	if fileHeader.Size > limit {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "Файл слишком большой"})
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка обработки файла"})
+	}
+
+	id, err := newAttachmentID()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка обработки файла"})
+	}
+
+	ctx := c.Request().Context()
+	att := &model.ChatAttachment{
+		ID:          id,
+		ChatID:      chat.GetId(),
+		UploaderID:  userID,
+		FileName:    sanitizeFileName(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+		StorageKey:  "chats/" + chat.GetId() + "/" + id,
+	}
+
+	if strings.HasPrefix(contentType, "image/") {
+		thumb, err := utils.MakeThumbnail(file, thumbnailMaxSide)
+		if err != nil {
+			if errors.Is(err, utils.ErrImageTooLarge) {
+				return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "Слишком большое разрешение изображения"})
+			}
+			return c.JSON(http.StatusUnsupportedMediaType, map[string]string{"error": "Повреждённое изображение"})
+		}
+		att.Width, att.Height = thumb.Width, thumb.Height
+		att.ThumbnailKey = att.StorageKey + "_thumb"
+		att.ThumbnailContentType = thumb.ContentType
+
+		if err := h.Storage.Put(ctx, att.ThumbnailKey, bytes.NewReader(thumb.Data), int64(len(thumb.Data)), thumb.ContentType); err != nil {
+			log.Printf("Ошибка сохранения миниатюры %s: %v", att.ThumbnailKey, err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка сохранения файла"})
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка обработки файла"})
+		}
+	}
+
+	if err := h.Storage.Put(ctx, att.StorageKey, file, att.Size, att.ContentType); err != nil {
+		log.Printf("Ошибка сохранения вложения %s: %v", att.StorageKey, err)
+		h.deleteAttachmentBlobs(att)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка сохранения файла"})
+	}
+
+	if _, err := h.DB.NewInsert().Model(att).Exec(ctx); err != nil {
+		h.deleteAttachmentBlobs(att)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка сохранения файла"})
+	}
+
+	return c.JSON(http.StatusCreated, h.attachmentResponse(att, userID))
+}
+
+// Удаляет файлы вложения из хранилища, например если запись в БД не сохранилась
+func (h *UserHandler) deleteAttachmentBlobs(att *model.ChatAttachment) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, key := range []string{att.StorageKey, att.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := h.Storage.Delete(ctx, key); err != nil {
+			log.Printf("Ошибка удаления файла %s: %v", key, err)
+		}
+	}
+}
+
+// Вложение чата с новыми подписанными ссылками
+func (h *UserHandler) GetChatAttachment(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	att := new(model.ChatAttachment)
+	err = h.DB.NewSelect().
+		Model(att).
+		Where("id = ?", c.Param("attachment_id")).
+		Where("chat_id = ?", chat.GetId()).
+		Scan(c.Request().Context())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Вложение не найдено"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка получения вложения"})
+	}
+
+	return c.JSON(http.StatusOK, h.attachmentResponse(att, userID))
+}
+
+// Скачивание вложения по подписанной ссылке. Ссылка работает без заголовка Authorization
+// (например в <img src>), но только пока её получатель остаётся участником чата.
+func (h *UserHandler) ServeChatAttachment(c echo.Context) error {
+	id := c.Param("attachment_id")
+	variant := c.QueryParam("variant")
+	userID, err1 := strconv.Atoi(c.QueryParam("uid"))
+	expires, err2 := strconv.ParseInt(c.QueryParam("expires"), 10, 64)
+	if err1 != nil || err2 != nil || (variant != "original" && variant != "thumbnail") {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректная ссылка"})
+	}
+	if !h.URLSigner.Verify(attachmentResource(id, variant), userID, expires, c.QueryParam("sig")) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Ссылка недействительна или устарела"})
+	}
+
+	ctx := c.Request().Context()
+
+	att := new(model.ChatAttachment)
+	if err := h.DB.NewSelect().Model(att).Where("id = ?", id).Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Вложение не найдено"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка получения вложения"})
+	}
+
+	chatResp, err := h.ChatServiceClient.GetChat(ctx, &chatpb.GetChatRequest{ChatId: att.ChatID})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка получения чата", err)
+	}
+	if !isParticipant(chatResp.GetChat(), strconv.Itoa(userID)) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Вложение не найдено"})
+	}
+
+	key, contentType := att.StorageKey, att.ContentType
+	if variant == "thumbnail" {
+		if att.ThumbnailKey == "" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Миниатюра отсутствует"})
+		}
+		key, contentType = att.ThumbnailKey, att.ThumbnailContentType
+	}
+
+	reader, err := h.Storage.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Вложение не найдено"})
+		}
+		log.Printf("Ошибка чтения файла %s: %v", key, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка получения вложения"})
+	}
+	defer reader.Close()
+
+	disposition := "attachment"
+	if strings.HasPrefix(contentType, "image/") || strings.HasPrefix(contentType, "video/") || strings.HasPrefix(contentType, "audio/") {
+		disposition = "inline"
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": att.FileName}))
+	header.Set("X-Content-Type-Options", "nosniff")
+	// Кэшировать можно не дольше срока действия ссылки
+	if ttl := time.Until(time.Unix(expires, 0)); ttl > 0 {
+		header.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(ttl.Seconds())))
+	}
+	if variant == "original" {
+		header.Set(echo.HeaderContentLength, strconv.FormatInt(att.Size, 10))
+	}
+
+	return c.Stream(http.StatusOK, contentType, reader)
+}
+
+// Проверяет, что ссылки на загруженные вложения в file_urls относятся к этому чату.
+// Внешние ссылки не проверяются.
+func (h *UserHandler) attachmentURLsBelongToChat(ctx context.Context, chatID string, fileURLs []string) (bool, error) {
+	var ids []string
+	for _, u := range fileURLs {
+		rest, ok := strings.CutPrefix(u, "/chats/")
+		if !ok {
+			continue
+		}
+		urlChatID, id, ok := strings.Cut(rest, "/attachments/")
+		if !ok || urlChatID != chatID || id == "" {
+			return false, nil
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return true, nil
+	}
+
+	count, err := h.DB.NewSelect().
+		Model((*model.ChatAttachment)(nil)).
+		Where("id IN (?)", bun.In(ids)).
+		Where("chat_id = ?", chatID).
+		Count(ctx)
+	if err != nil {
+		return false, err
+	}
+	return count == len(uniqueStrings(ids)), nil
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
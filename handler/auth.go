@@ -9,8 +9,6 @@ import (
     "github.com/golang-jwt/jwt/v4"
 )
 
-var jwtSecret = []byte("supersecretkey")
-
 // Структура для ответа с токеном
 type TokenResponse struct {
     Token string `json:"token"`
@@ -82,10 +80,10 @@ func (h *UserHandler) Login(c echo.Context) error {
     // Генерируем JWT токен
     token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
         "user_id": user.ID,
-        "exp":     time.Now().Add(12 * time.Hour).Unix(),
+        "exp":     time.Now().Add(h.TokenTTL).Unix(),
     })
 
-    tokenString, err := token.SignedString(jwtSecret)
+    tokenString, err := token.SignedString(h.JWTSecret)
     if err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Не удалось сгенерировать токен"})
     }
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"api-service/chatclient"
+	"api-service/fake"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+
+	authpb "api-service/proto/auth-service/proto"
+	chatpb "api-service/proto/chat-service/proto"
+)
+
+// gatewayEnv — шлюз к chat-service поверх фейковых ChatService и AuthService в bufconn
+type gatewayEnv struct {
+	srv  *fake.Server
+	echo *echo.Echo
+}
+
+func newGatewayEnv(t *testing.T, userIDs ...int32) *gatewayEnv {
+	t.Helper()
+	srv := fake.Start()
+	t.Cleanup(srv.Close)
+	for _, id := range userIDs {
+		srv.Auth.AddUser(&authpb.PublicUser{Id: id, Name: "user" + strconv.Itoa(int(id))})
+	}
+
+	chatClient, err := chatclient.New(chatclient.Config{
+		Addr:        fake.Target,
+		DialOptions: []grpc.DialOption{srv.DialOption()},
+	})
+	if err != nil {
+		t.Fatalf("chatclient.New: %v", err)
+	}
+	t.Cleanup(func() { chatClient.Close() })
+
+	authConn, err := srv.Conn()
+	if err != nil {
+		t.Fatalf("подключение к AuthService: %v", err)
+	}
+	t.Cleanup(func() { authConn.Close() })
+
+	h := &UserHandler{
+		ChatServiceClient: chatClient,
+		AuthServiceClient: authpb.NewAuthServiceClient(authConn),
+	}
+
+	// Вместо JWT пользователь берётся из заголовка X-User-ID
+	e := echo.New()
+	g := e.Group("/chats", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if id, err := strconv.Atoi(c.Request().Header.Get("X-User-ID")); err == nil {
+				c.Set("user_id", id)
+			}
+			return next(c)
+		}
+	})
+	g.POST("", h.CreateChat)
+	g.POST("/:id/messages", h.SendMessage)
+	g.GET("/:id/messages", h.GetMessages)
+	g.DELETE("/:id/messages/:message_id", h.DeleteMessage)
+
+	return &gatewayEnv{srv: srv, echo: e}
+}
+
+func (env *gatewayEnv) do(t *testing.T, userID int, method, path, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-User-ID", strconv.Itoa(userID))
+	rec := httptest.NewRecorder()
+	env.echo.ServeHTTP(rec, req)
+
+	var resp map[string]interface{}
+	if rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s %s: некорректный JSON %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestChatGatewayGroupChatMessages(t *testing.T) {
+	env := newGatewayEnv(t, 1, 2, 3)
+
+	code, chat := env.do(t, 1, http.MethodPost, "/chats", `{"name":"team","participants":[2,3],"is_group":true}`)
+	if code != http.StatusCreated {
+		t.Fatalf("создание чата: код %d, ответ %v", code, chat)
+	}
+	chatID, _ := chat["id"].(string)
+
+	code, msg := env.do(t, 2, http.MethodPost, "/chats/"+chatID+"/messages", `{"content":"привет"}`)
+	if code != http.StatusCreated {
+		t.Fatalf("отправка сообщения: код %d, ответ %v", code, msg)
+	}
+	if msg["sender_id"] != "2" || msg["content"] != "привет" {
+		t.Fatalf("неожиданное сообщение: %v", msg)
+	}
+
+	code, page := env.do(t, 3, http.MethodGet, "/chats/"+chatID+"/messages", "")
+	if code != http.StatusOK {
+		t.Fatalf("получение сообщений: код %d, ответ %v", code, page)
+	}
+	messages, _ := page["messages"].([]interface{})
+	if len(messages) != 1 {
+		t.Fatalf("ожидалось одно сообщение, получено %v", page["messages"])
+	}
+
+	// Чужой пользователь не видит чат
+	if code, _ := env.do(t, 4, http.MethodGet, "/chats/"+chatID+"/messages", ""); code != http.StatusNotFound {
+		t.Fatalf("чужой пользователь: код %d, ожидался 404", code)
+	}
+}
+
+func TestChatGatewayCreateChatDeniedByPolicy(t *testing.T) {
+	env := newGatewayEnv(t, 1, 2)
+	env.srv.Auth.Deny(1, 2, authpb.InteractionAction_INTERACTION_ACTION_DIRECT_MESSAGE, "blocked")
+
+	code, resp := env.do(t, 1, http.MethodPost, "/chats", `{"participants":[2]}`)
+	if code != http.StatusForbidden || resp["reason"] != "blocked" {
+		t.Fatalf("код %d, ответ %v; ожидался 403 с reason=blocked", code, resp)
+	}
+
+	chats, err := env.srv.Chat.ListUserChats(context.Background(), &chatpb.ListUserChatsRequest{UserId: "1"})
+	if err != nil {
+		t.Fatalf("ListUserChats: %v", err)
+	}
+	if len(chats.GetChats()) != 0 {
+		t.Fatalf("запрещённый чат всё же создан: %v", chats.GetChats())
+	}
+
+	// Запрет касается только личных сообщений: в группу добавить можно
+	if code, resp := env.do(t, 1, http.MethodPost, "/chats", `{"participants":[2],"is_group":true}`); code != http.StatusCreated {
+		t.Fatalf("группа: код %d, ответ %v", code, resp)
+	}
+}
+
+func TestChatGatewayCreateChatUnknownUser(t *testing.T) {
+	env := newGatewayEnv(t, 1)
+
+	code, resp := env.do(t, 1, http.MethodPost, "/chats", `{"participants":[42]}`)
+	if code != http.StatusNotFound || resp["user_id"] != "42" {
+		t.Fatalf("код %d, ответ %v; ожидался 404 для user_id=42", code, resp)
+	}
+}
+
+func TestChatGatewayMessageFromAnotherChat(t *testing.T) {
+	env := newGatewayEnv(t, 1, 2, 3)
+
+	_, first := env.do(t, 1, http.MethodPost, "/chats", `{"participants":[2,3],"is_group":true}`)
+	_, second := env.do(t, 1, http.MethodPost, "/chats", `{"participants":[2],"is_group":true}`)
+	firstID, _ := first["id"].(string)
+	secondID, _ := second["id"].(string)
+
+	_, msg := env.do(t, 1, http.MethodPost, "/chats/"+firstID+"/messages", `{"content":"секрет"}`)
+	msgID, _ := msg["id"].(string)
+
+	// ID сообщения нельзя подставить к другому чату, даже если пользователь в нём состоит
+	if code, _ := env.do(t, 1, http.MethodDelete, "/chats/"+secondID+"/messages/"+msgID, ""); code != http.StatusNotFound {
+		t.Fatalf("удаление через чужой чат: код %d, ожидался 404", code)
+	}
+	if code, _ := env.do(t, 1, http.MethodDelete, "/chats/"+firstID+"/messages/"+msgID, ""); code != http.StatusNoContent {
+		t.Fatalf("удаление своего сообщения: код %d, ожидался 204", code)
+	}
+}
+
+func TestChatGatewayCreateDirectChatWithSelf(t *testing.T) {
+	env := newGatewayEnv(t, 1)
+
+	if code, resp := env.do(t, 1, http.MethodPost, "/chats", `{"participants":[1]}`); code != http.StatusBadRequest {
+		t.Fatalf("код %d, ответ %v; ожидался 400", code, resp)
+	}
+}
+
+func TestChatGatewaySendMessageDeniedByPolicy(t *testing.T) {
+	env := newGatewayEnv(t, 1, 2)
+
+	_, chat := env.do(t, 1, http.MethodPost, "/chats", `{"participants":[2]}`)
+	chatID, _ := chat["id"].(string)
+
+	// Блокировка после создания чата проверяется тем же RPC CanInteract
+	env.srv.Auth.Deny(2, 1, authpb.InteractionAction_INTERACTION_ACTION_SEND_MESSAGE, "blocked")
+
+	code, resp := env.do(t, 2, http.MethodPost, "/chats/"+chatID+"/messages", `{"content":"привет"}`)
+	if code != http.StatusForbidden || resp["reason"] != "blocked" {
+		t.Fatalf("код %d, ответ %v; ожидался 403 с reason=blocked", code, resp)
+	}
+	if code, resp := env.do(t, 1, http.MethodPost, "/chats/"+chatID+"/messages", `{"content":"привет"}`); code != http.StatusCreated {
+		t.Fatalf("обратное направление: код %d, ответ %v", code, resp)
+	}
+}
@@ -0,0 +1,659 @@
+package handler
+
+import (
+	"api-service/model"
+	"api-service/policy"
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	authpb "api-service/proto/auth-service/proto"
+	chatpb "api-service/proto/chat-service/proto"
+)
+
+const (
+	defaultMessagesPageSize = 50
+	maxMessagesPageSize     = 100
+)
+
+// Сериализация ответов chat-service: snake_case как в остальном API
+var protoJSON = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+// Ответ protobuf-сообщением
+func respondProto(c echo.Context, code int, msg proto.Message) error {
+	data, err := protoJSON.Marshal(msg)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка сериализации ответа"})
+	}
+	return c.JSONBlob(code, data)
+}
+
+// Соответствие кодов gRPC кодам HTTP
+func httpStatusFromGRPC(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return http.StatusRequestTimeout
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Хелпер для ответа на ошибку chat-service
+func respondWithGRPCError(c echo.Context, message string, err error) error {
+	st, _ := status.FromError(err)
+	return c.JSON(httpStatusFromGRPC(st.Code()), map[string]string{"error": message, "details": st.Message()})
+}
+
+func isParticipant(chat *chatpb.Chat, userID string) bool {
+	for _, p := range chat.GetParticipants() {
+		if p == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Загружает чат и проверяет, что текущий пользователь в нём участвует.
+// При ошибке ответ уже отправлен клиенту и возвращается nil-чат.
+func (h *UserHandler) loadChatForMember(c echo.Context, userID int) (*chatpb.Chat, error) {
+	resp, err := h.ChatServiceClient.GetChat(c.Request().Context(), &chatpb.GetChatRequest{ChatId: c.Param("id")})
+	if err != nil {
+		return nil, respondWithGRPCError(c, "Ошибка получения чата", err)
+	}
+	if !isParticipant(resp.GetChat(), strconv.Itoa(userID)) {
+		// Не раскрываем существование чужих чатов
+		return nil, c.JSON(http.StatusNotFound, map[string]string{"error": "Чат не найден"})
+	}
+	return resp.GetChat(), nil
+}
+
+// Находит сообщение в чате. chat-service отвечает NOT_FOUND и на сообщение
+// из другого чата, так что ID из URL нельзя подставить к чужому чату.
+func (h *UserHandler) findMessage(ctx context.Context, chatID, messageID string) (*chatpb.Message, error) {
+	resp, err := h.ChatServiceClient.GetMessage(ctx, &chatpb.GetMessageRequest{ChatId: chatID, MessageId: messageID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetMessage(), nil
+}
+
+// Действия политики взаимодействия в терминах AuthService
+var interactionActions = map[string]authpb.InteractionAction{
+	policy.ActionDirectMessage: authpb.InteractionAction_INTERACTION_ACTION_DIRECT_MESSAGE,
+	policy.ActionAddToGroup:    authpb.InteractionAction_INTERACTION_ACTION_ADD_TO_GROUP,
+	policy.ActionSendMessage:   authpb.InteractionAction_INTERACTION_ACTION_SEND_MESSAGE,
+}
+
+// Решение политики взаимодействия через RPC CanInteract, тот же, которым
+// пользуется chat-service
+func canInteract(ctx context.Context, client authpb.AuthServiceClient, actorID, targetID int, action string) (*authpb.CanInteractResponse, error) {
+	return client.CanInteract(ctx, &authpb.CanInteractRequest{
+		ActorId:  int32(actorID),
+		TargetId: int32(targetID),
+		Action:   interactionActions[action],
+	})
+}
+
+// Проверяет политику взаимодействия с каждым из пользователей через canInteract.
+// При отказе ответ уже отправлен клиенту и возвращается false.
+func (h *UserHandler) checkInteraction(c echo.Context, actorID int, targets []int, action string) (bool, error) {
+	for _, targetID := range targets {
+		decision, err := canInteract(c.Request().Context(), h.AuthServiceClient, actorID, targetID, action)
+		if err != nil {
+			return false, c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка проверки прав"})
+		}
+		if decision.GetAllowed() {
+			continue
+		}
+		if decision.GetReason() == policy.ReasonTargetNotFound {
+			return false, c.JSON(http.StatusNotFound, map[string]string{"error": "Пользователь не найден", "user_id": strconv.Itoa(targetID)})
+		}
+		return false, c.JSON(http.StatusForbidden, map[string]string{
+			"error":   "Взаимодействие с пользователем запрещено",
+			"reason":  decision.GetReason(),
+			"user_id": strconv.Itoa(targetID),
+		})
+	}
+	return true, nil
+}
+
+// Собеседник в личном чате
+func directPeer(chat *chatpb.Chat, userID int) (int, bool) {
+	for _, p := range chat.GetParticipants() {
+		if id, err := strconv.Atoi(p); err == nil && id != userID {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// Создание чата
+func (h *UserHandler) CreateChat(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	req := new(model.CreateChatRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Неверные данные"})
+	}
+	if len(req.Participants) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Не указаны участники чата"})
+	}
+	if !req.IsGroup && len(req.Participants) != 1 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Личный чат должен содержать ровно одного собеседника"})
+	}
+	if !req.IsGroup && req.Participants[0] == userID {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Нельзя создать личный чат с самим собой"})
+	}
+
+	// Создатель всегда участник чата
+	participants := []string{strconv.Itoa(userID)}
+	targets := make([]int, 0, len(req.Participants))
+	for _, id := range req.Participants {
+		if id != userID {
+			participants = append(participants, strconv.Itoa(id))
+			targets = append(targets, id)
+		}
+	}
+
+	action := policy.ActionDirectMessage
+	if req.IsGroup {
+		action = policy.ActionAddToGroup
+	}
+	if allowed, err := h.checkInteraction(c, userID, targets, action); !allowed {
+		return err
+	}
+
+	resp, err := h.ChatServiceClient.CreateChat(c.Request().Context(), &chatpb.CreateChatRequest{
+		Name:         req.Name,
+		Description:  req.Description,
+		CreatorId:    strconv.Itoa(userID),
+		Participants: participants,
+		IsGroup:      req.IsGroup,
+	})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка создания чата", err)
+	}
+
+	return respondProto(c, http.StatusCreated, resp.GetChat())
+}
+
+// Список чатов текущего пользователя
+func (h *UserHandler) ListMyChats(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	resp, err := h.ChatServiceClient.ListUserChats(c.Request().Context(), &chatpb.ListUserChatsRequest{UserId: strconv.Itoa(userID)})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка получения списка чатов", err)
+	}
+
+	return respondProto(c, http.StatusOK, resp)
+}
+
+// Входящие: чаты пользователя с последним сообщением и счётчиком непрочитанных,
+// свежие сверху
+func (h *UserHandler) ChatInbox(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	resp, err := h.ChatServiceClient.ListUserChats(c.Request().Context(), &chatpb.ListUserChatsRequest{UserId: strconv.Itoa(userID)})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка получения списка чатов", err)
+	}
+
+	chats := resp.GetChats()
+	sort.SliceStable(chats, func(i, j int) bool {
+		return lastActivity(chats[i]).After(lastActivity(chats[j]))
+	})
+
+	// Сервер может не заполнять сумму — считаем сами
+	if resp.TotalUnread == 0 {
+		for _, chat := range chats {
+			resp.TotalUnread += chat.GetUnreadCount()
+		}
+	}
+
+	return respondProto(c, http.StatusOK, resp)
+}
+
+// Время последней активности в чате: последнее сообщение или создание чата
+func lastActivity(chat *chatpb.Chat) time.Time {
+	if msg := chat.GetLastMessage(); msg != nil && msg.GetCreatedAt() != nil {
+		return msg.GetCreatedAt().AsTime()
+	}
+	return chat.GetCreatedAt().AsTime()
+}
+
+// Получение чата
+func (h *UserHandler) GetChat(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	return respondProto(c, http.StatusOK, chat)
+}
+
+// Обновление чата (только создатель)
+func (h *UserHandler) UpdateChat(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+	if chat.GetCreatorId() != strconv.Itoa(userID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Изменять чат может только его создатель"})
+	}
+
+	req := new(model.UpdateChatRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Неверные данные"})
+	}
+
+	resp, err := h.ChatServiceClient.UpdateChat(c.Request().Context(), &chatpb.UpdateChatRequest{
+		ChatId:      chat.GetId(),
+		Name:        req.Name,
+		Description: req.Description,
+		AvatarUrl:   req.AvatarURL,
+	})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка обновления чата", err)
+	}
+
+	return respondProto(c, http.StatusOK, resp.GetChat())
+}
+
+// Удаление чата (только создатель)
+func (h *UserHandler) DeleteChat(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+	if chat.GetCreatorId() != strconv.Itoa(userID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Удалить чат может только его создатель"})
+	}
+
+	if _, err := h.ChatServiceClient.DeleteChat(c.Request().Context(), &chatpb.DeleteChatRequest{ChatId: chat.GetId()}); err != nil {
+		return respondWithGRPCError(c, "Ошибка удаления чата", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Отправка сообщения
+func (h *UserHandler) SendMessage(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	req := new(model.SendMessageRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Неверные данные"})
+	}
+	if req.Content == "" && len(req.FileURLs) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Пустое сообщение"})
+	}
+
+	// Вложения из других чатов прикреплять нельзя
+	ok, err = h.attachmentURLsBelongToChat(c.Request().Context(), chat.GetId(), req.FileURLs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка проверки вложений"})
+	}
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Вложение не найдено в этом чате"})
+	}
+
+	// В личном чате блокировка после создания чата запрещает дальнейшую переписку
+	if !chat.GetIsGroup() {
+		if peerID, ok := directPeer(chat, userID); ok {
+			if allowed, err := h.checkInteraction(c, userID, []int{peerID}, policy.ActionSendMessage); !allowed {
+				return err
+			}
+		}
+	}
+
+	resp, err := h.ChatServiceClient.SendMessage(c.Request().Context(), &chatpb.SendMessageRequest{
+		ChatId:   chat.GetId(),
+		SenderId: strconv.Itoa(userID),
+		Content:  req.Content,
+		FileUrls: req.FileURLs,
+	})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка отправки сообщения", err)
+	}
+
+	return respondProto(c, http.StatusCreated, resp.GetMessage())
+}
+
+// Получение сообщений чата постранично: ?before=, ?after=, ?limit=, ?q=
+func (h *UserHandler) GetMessages(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	before, after := c.QueryParam("before"), c.QueryParam("after")
+	if before != "" && after != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Нельзя одновременно указывать before и after"})
+	}
+
+	limit := defaultMessagesPageSize
+	if l := c.QueryParam("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > maxMessagesPageSize {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный limit"})
+		}
+		limit = n
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	resp, err := h.ChatServiceClient.GetMessages(c.Request().Context(), &chatpb.GetMessagesRequest{
+		ChatId:   chat.GetId(),
+		BeforeId: before,
+		AfterId:  after,
+		PageSize: int32(limit),
+		Query:    c.QueryParam("q"),
+	})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка получения сообщений", err)
+	}
+
+	return respondProto(c, http.StatusOK, resp)
+}
+
+// Редактирование своего сообщения
+func (h *UserHandler) EditMessage(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	req := new(model.EditMessageRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Неверные данные"})
+	}
+	if req.Content == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Пустое сообщение"})
+	}
+
+	msg, err := h.findMessage(c.Request().Context(), chat.GetId(), c.Param("message_id"))
+	if err != nil {
+		return respondWithGRPCError(c, "Сообщение не найдено", err)
+	}
+	if msg.GetSenderId() != strconv.Itoa(userID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Можно редактировать только свои сообщения"})
+	}
+
+	resp, err := h.ChatServiceClient.EditMessage(c.Request().Context(), &chatpb.EditMessageRequest{
+		MessageId: msg.GetId(),
+		Content:   req.Content,
+	})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка редактирования сообщения", err)
+	}
+
+	return respondProto(c, http.StatusOK, resp.GetMessage())
+}
+
+// Удаление своего сообщения
+func (h *UserHandler) DeleteMessage(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	msg, err := h.findMessage(c.Request().Context(), chat.GetId(), c.Param("message_id"))
+	if err != nil {
+		return respondWithGRPCError(c, "Сообщение не найдено", err)
+	}
+	if msg.GetSenderId() != strconv.Itoa(userID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Можно удалять только свои сообщения"})
+	}
+
+	if _, err := h.ChatServiceClient.DeleteMessage(c.Request().Context(), &chatpb.DeleteMessageRequest{MessageId: msg.GetId()}); err != nil {
+		return respondWithGRPCError(c, "Ошибка удаления сообщения", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Список участников чата
+func (h *UserHandler) ListChatParticipants(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	resp, err := h.ChatServiceClient.ListChatParticipants(c.Request().Context(), &chatpb.ListChatParticipantsRequest{ChatId: chat.GetId()})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка получения участников", err)
+	}
+
+	return respondProto(c, http.StatusOK, resp)
+}
+
+// Добавление участника в групповой чат
+func (h *UserHandler) AddParticipant(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+	if !chat.GetIsGroup() {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "В личный чат нельзя добавить участников"})
+	}
+
+	req := new(model.AddParticipantRequest)
+	if err := c.Bind(req); err != nil || req.UserID == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Неверные данные"})
+	}
+
+	if allowed, err := h.checkInteraction(c, userID, []int{req.UserID}, policy.ActionAddToGroup); !allowed {
+		return err
+	}
+
+	resp, err := h.ChatServiceClient.AddParticipant(c.Request().Context(), &chatpb.AddParticipantRequest{
+		ChatId: chat.GetId(),
+		UserId: strconv.Itoa(req.UserID),
+	})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка добавления участника", err)
+	}
+
+	return respondProto(c, http.StatusOK, resp.GetChat())
+}
+
+// Удаление участника: создатель может удалить любого, остальные — только себя (выход из чата)
+func (h *UserHandler) RemoveParticipant(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	targetID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID пользователя"})
+	}
+	if targetID != userID && chat.GetCreatorId() != strconv.Itoa(userID) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Удалять участников может только создатель чата"})
+	}
+
+	resp, err := h.ChatServiceClient.RemoveParticipant(c.Request().Context(), &chatpb.RemoveParticipantRequest{
+		ChatId: chat.GetId(),
+		UserId: strconv.Itoa(targetID),
+	})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка удаления участника", err)
+	}
+
+	return respondProto(c, http.StatusOK, resp.GetChat())
+}
+
+// Установка реакции на сообщение
+func (h *UserHandler) SetMessageReaction(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	req := new(model.SetReactionRequest)
+	if err := c.Bind(req); err != nil || req.Reaction == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Неверные данные"})
+	}
+
+	msg, err := h.findMessage(c.Request().Context(), chat.GetId(), c.Param("message_id"))
+	if err != nil {
+		return respondWithGRPCError(c, "Сообщение не найдено", err)
+	}
+
+	resp, err := h.ChatServiceClient.SetMessageReaction(c.Request().Context(), &chatpb.SetMessageReactionRequest{
+		MessageId: msg.GetId(),
+		UserId:    strconv.Itoa(userID),
+		Reaction:  req.Reaction,
+	})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка установки реакции", err)
+	}
+
+	return respondProto(c, http.StatusOK, resp.GetMessage())
+}
+
+// Удаление своей реакции на сообщение
+func (h *UserHandler) RemoveMessageReaction(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	msg, err := h.findMessage(c.Request().Context(), chat.GetId(), c.Param("message_id"))
+	if err != nil {
+		return respondWithGRPCError(c, "Сообщение не найдено", err)
+	}
+
+	resp, err := h.ChatServiceClient.RemoveMessageReaction(c.Request().Context(), &chatpb.RemoveMessageReactionRequest{
+		MessageId: msg.GetId(),
+		UserId:    strconv.Itoa(userID),
+	})
+	if err != nil {
+		return respondWithGRPCError(c, "Ошибка удаления реакции", err)
+	}
+
+	return respondProto(c, http.StatusOK, resp.GetMessage())
+}
+
+// Отметка сообщения как прочитанного
+func (h *UserHandler) MarkMessageAsRead(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	// chat_id ограничивает отметку этим чатом, отдельный поиск сообщения не нужен
+	if _, err := h.ChatServiceClient.MarkMessageAsRead(c.Request().Context(), &chatpb.MarkMessageAsReadRequest{
+		ChatId:    chat.GetId(),
+		MessageId: c.Param("message_id"),
+		UserId:    strconv.Itoa(userID),
+	}); err != nil {
+		return respondWithGRPCError(c, "Ошибка отметки о прочтении", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
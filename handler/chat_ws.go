@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	chatpb "api-service/proto/chat-service/proto"
+)
+
+const (
+	wsWriteTimeout   = 10 * time.Second
+	wsPongTimeout    = 60 * time.Second
+	wsPingInterval   = 25 * time.Second
+	wsMaxFrameSize   = 4 * 1024
+	streamMaxRetries = 10
+	streamMinBackoff = 500 * time.Millisecond
+	streamMaxBackoff = 10 * time.Second
+)
+
+// wsUpgrader принимает соединения со своего сайта и из WSAllowedOrigins. Токен
+// приходит в ?token= и может утечь через логи, поэтому чужой сайт с ним сокет не откроет
+func (h *UserHandler) wsUpgrader() *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin:     h.checkWSOrigin,
+	}
+}
+
+// checkWSOrigin пропускает запросы без Origin (не из браузера), со своего хоста
+// и с сайтов из WSAllowedOrigins
+func (h *UserHandler) checkWSOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range h.WSAllowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Сообщение от клиента по WebSocket
+type wsClientFrame struct {
+	Type string `json:"type"` // "typing"
+}
+
+// ChatEventsWS — WebSocket-мост к потоку SubscribeChatEvents.
+// Клиент при переподключении передаёт ?last_event_id=, чтобы получить пропущенные события.
+func (h *UserHandler) ChatEventsWS(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+	}
+
+	// Проверяем членство до апгрейда, чтобы вернуть обычную HTTP-ошибку
+	chat, err := h.loadChatForMember(c, userID)
+	if chat == nil {
+		return err
+	}
+
+	conn, err := h.wsUpgrader().Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// Upgrader уже отправил ответ с ошибкой
+		log.Printf("Ошибка апгрейда WebSocket: %v", err)
+		return nil
+	}
+	defer conn.Close()
+
+	// После апгрейда контекст запроса не отслеживает соединение, отменяем сами
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan *chatpb.ChatEvent)
+	relayErr := make(chan error, 1)
+
+	go h.readChatFrames(ctx, cancel, conn, chat.GetId(), strconv.Itoa(userID))
+	go func() {
+		relayErr <- h.relayChatEvents(ctx, chat.GetId(), strconv.Itoa(userID), c.QueryParam("last_event_id"), events)
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-relayErr:
+			closeCode := websocket.CloseTryAgainLater
+			if code := status.Code(err); code == codes.PermissionDenied || code == codes.NotFound {
+				closeCode = websocket.ClosePolicyViolation
+			}
+			writeClose(conn, closeCode, "поток событий чата недоступен")
+			return nil
+		case event := <-events:
+			data, err := protoJSON.Marshal(event)
+			if err != nil {
+				log.Printf("Ошибка сериализации события чата: %v", err)
+				continue
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return nil
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func writeClose(conn *websocket.Conn, code int, text string) {
+	msg := websocket.FormatCloseMessage(code, text)
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteTimeout))
+}
+
+// readChatFrames читает кадры клиента: ответы на ping и события набора текста.
+// При закрытии соединения отменяет общий контекст.
+func (h *UserHandler) readChatFrames(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, chatID, userID string) {
+	defer cancel()
+
+	conn.SetReadLimit(wsMaxFrameSize)
+	conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+
+		var frame wsClientFrame
+		if err := json.Unmarshal(data, &frame); err != nil {
+			continue
+		}
+		if frame.Type == "typing" {
+			if _, err := h.ChatServiceClient.SendTyping(ctx, &chatpb.SendTypingRequest{ChatId: chatID, UserId: userID}); err != nil {
+				log.Printf("Ошибка отправки события набора текста: %v", err)
+			}
+		}
+	}
+}
+
+// relayChatEvents держит подписку на события чата и переподключается с последнего
+// полученного события при обрыве. Возвращает ошибку, если поток восстановить не удалось.
+func (h *UserHandler) relayChatEvents(ctx context.Context, chatID, userID, lastEventID string, out chan<- *chatpb.ChatEvent) error {
+	backoff := streamMinBackoff
+	failures := 0
+
+	for {
+		stream, err := h.ChatServiceClient.SubscribeChatEvents(ctx, &chatpb.SubscribeChatEventsRequest{
+			ChatId:       chatID,
+			UserId:       userID,
+			AfterEventId: lastEventID,
+		})
+		for err == nil {
+			var event *chatpb.ChatEvent
+			event, err = stream.Recv()
+			if err != nil {
+				break
+			}
+			// Поток жив — сбрасываем счётчик неудач
+			failures, backoff = 0, streamMinBackoff
+			lastEventID = event.GetId()
+
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !isRetryableStreamError(err) {
+			return err
+		}
+
+		failures++
+		if failures > streamMaxRetries {
+			return err
+		}
+		log.Printf("Поток событий чата %s прерван (%v), переподключение через %s", chatID, err, backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if backoff *= 2; backoff > streamMaxBackoff {
+			backoff = streamMaxBackoff
+		}
+	}
+}
+
+// Ошибки, после которых имеет смысл переподключиться
+func isRetryableStreamError(err error) bool {
+	if errors.Is(err, io.EOF) {
+		return true // Сервер закрыл поток, например при перезапуске
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Internal, codes.Aborted, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
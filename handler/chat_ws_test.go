@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckWSOrigin(t *testing.T) {
+	h := &UserHandler{WSAllowedOrigins: []string{"https://app.example.com/"}}
+
+	cases := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},                          // Не браузер
+		{"http://api.example.com", true},    // Свой хост
+		{"https://app.example.com", true},   // Из списка
+		{"https://APP.example.com", true},   // Регистр хоста не важен
+		{"https://evil.example.com", false}, // Чужой сайт
+		{"http://app.example.com", false},   // Другая схема
+		{"://bad", false},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", "http://api.example.com/chats/1/ws", nil)
+		if tc.origin != "" {
+			req.Header.Set("Origin", tc.origin)
+		}
+		if got := h.checkWSOrigin(req); got != tc.want {
+			t.Errorf("Origin %q: получено %v, ожидалось %v", tc.origin, got, tc.want)
+		}
+	}
+}
@@ -2,15 +2,35 @@ package handler
 
 import (
 	"api-service/model"
+	"api-service/policy"
+	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
 )
 
-// Добавление комментария к посту
+// Размер страницы комментариев
+const (
+	defaultCommentsPageSize = 20
+	maxCommentsPageSize     = 100
+)
+
+var (
+	// errParentCommentNotFound — родительский комментарий не найден в этом посте
+	errParentCommentNotFound = errors.New("parent comment not found")
+	// errReplyTooDeep — ответ превысил бы PostHandler.CommentMaxDepth
+	errReplyTooDeep = errors.New("reply depth limit reached")
+)
+
+// Добавление комментария к посту или ответа на комментарий (parent_id)
 func (h *PostHandler) CommentOnPost(c echo.Context) error {
 	// Получаем PostID из параметра маршрута
 	postIDParam := c.Param("id")
@@ -24,7 +44,8 @@ func (h *PostHandler) CommentOnPost(c echo.Context) error {
   
 	// Привязываем тело запроса
 	req := new(struct {
-		Content string `json:"content"`
+		Content  string `json:"content"`
+		ParentID *int   `json:"parent_id,omitempty"`
 	})
 	if err := c.Bind(req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный запрос"})
@@ -32,28 +53,78 @@ func (h *PostHandler) CommentOnPost(c echo.Context) error {
   
 	// Создаём комментарий
 	comment := &model.Comment{
-		PostID:  postID,
-		UserID:  userID,
-		Content: req.Content,
+		PostID:   postID,
+		UserID:   userID,
+		Content:  req.Content,
+		ParentID: req.ParentID,
 	}
   
-	// Сохраняем комментарий
-	_, err = h.DB.NewInsert().Model(comment).Exec(c.Request().Context())
+	// Сохраняем комментарий и обновляем счетчики поста и родителя в одной транзакции
+	err = h.DB.RunInTx(c.Request().Context(), nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := checkCanComment(ctx, tx, userID, postID); err != nil {
+			return err
+		}
+
+		if req.ParentID != nil {
+			parent := new(model.Comment)
+			err := tx.NewSelect().Model(parent).Column("id", "post_id", "depth", "hidden_at").Where("id = ?", *req.ParentID).Scan(ctx)
+			if errors.Is(err, sql.ErrNoRows) || (err == nil && (parent.PostID != postID || parent.HiddenAt != nil)) {
+				return errParentCommentNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if parent.Depth >= h.CommentMaxDepth {
+				return errReplyTooDeep
+			}
+			comment.Depth = parent.Depth + 1
+		}
+
+		if _, err := tx.NewInsert().Model(comment).Exec(ctx); err != nil {
+			return err
+		}
+		if err := adjustRepliesCount(ctx, tx, comment.ParentID, 1); err != nil {
+			return err
+		}
+		_, err = tx.NewUpdate().
+			Model((*model.Post)(nil)).
+			WhereAllWithDeleted().
+			Set("comments_count = comments_count + 1").
+			Where("id = ?", postID).
+			Exec(ctx)
+		return err
+	})
 	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден"})
+		case errors.Is(err, errCommentsRestricted):
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Автор поста ограничил, кто может комментировать"})
+		case errors.Is(err, errParentCommentNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Комментарий, на который вы отвечаете, не найден"})
+		case errors.Is(err, errReplyTooDeep):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("Превышена максимальная вложенность ответов (%d)", h.CommentMaxDepth),
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при добавлении комментария"})
 	}
   
-	  // Обновляем счетчик комментариев
-	  _, err = h.DB.NewUpdate().
-		  Model(&model.Post{}).
-		  Set("comments_count = comments_count + 1").
-		  Where("id = ?", postID).
-		  Exec(c.Request().Context())
-	  if err != nil {
-		  return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при обновлении счетчика комментариев"})
-	  }
-	  
-	return c.NoContent(http.StatusCreated)
+	return c.JSON(http.StatusCreated, comment)
+}
+
+// adjustRepliesCount меняет счётчик ответов родительского комментария на delta
+func adjustRepliesCount(ctx context.Context, db bun.IDB, parentID *int, delta int) error {
+	if parentID == nil {
+		return nil
+	}
+	_, err := db.NewUpdate().
+		Model((*model.Comment)(nil)).
+		WhereAllWithDeleted().
+		Set("replies_count = GREATEST(replies_count + ?, 0)", delta).
+		Where("id = ?", *parentID).
+		Exec(ctx)
+	return err
 }
 
 // Обновление комментария
@@ -97,6 +168,7 @@ func (h *PostHandler) UpdateComment(c echo.Context) error {
 	_, err = h.DB.NewUpdate().
 		Model(comment).
 		Set("content = ?", req.Content).
+		Set("edited_at = ?", time.Now()).
 		Where("id = ?", commentID).
 		Exec(ctx)
 	if err != nil {
@@ -106,7 +178,30 @@ func (h *PostHandler) UpdateComment(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"message": "Comment updated successfully"})
 }
 
-// Получение комментариев по ID поста
+// commentCursor — позиция в выдаче комментариев: значение ключа сортировки и ID
+type commentCursor struct {
+	Key int64 `json:"k"`
+	ID  int   `json:"id"`
+}
+
+func encodeCommentCursor(cur commentCursor) string {
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCommentCursor(s string) (commentCursor, error) {
+	var cur commentCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cur, err
+	}
+	err = json.Unmarshal(raw, &cur)
+	return cur, err
+}
+
+// Получение комментариев поста постранично: ?sort=newest|oldest|top, ?limit=, ?cursor=.
+// Без ?parent_id= отдаются комментарии к посту, с ним — ответы на этот комментарий.
+// Закреплённый комментарий идёт первым на первой странице, скрытые не отдаются
 func (h *PostHandler) GetCommentsByPostID(c echo.Context) error {
 	postIDParam := c.Param("id")
 	postID, err := strconv.Atoi(postIDParam)
@@ -114,19 +209,184 @@ func (h *PostHandler) GetCommentsByPostID(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID поста"})
 	}
 
-	comments := make([]model.Comment, 0)
+	sort := c.QueryParam("sort")
+	if sort == "" {
+		sort = model.CommentSortOldest
+	}
+	if sort != model.CommentSortNewest && sort != model.CommentSortOldest && sort != model.CommentSortTop {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректная сортировка"})
+	}
+	limit := defaultCommentsPageSize
+	if l := c.QueryParam("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > maxCommentsPageSize {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный limit"})
+		}
+		limit = n
+	}
+	var cursor *commentCursor
+	if s := c.QueryParam("cursor"); s != "" {
+		cur, err := decodeCommentCursor(s)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный cursor"})
+		}
+		cursor = &cur
+	}
+	ctx := c.Request().Context()
+
+	// Комментарии удалённого и скрытого от читателя поста не показываются
+	post := new(model.Post)
 	err = h.DB.NewSelect().
-		Model(&comments).
-		Where("post_id = ?", postID).
-		Scan(c.Request().Context())
+		Model(post).
+		Column("id", "user_id", "pinned_comment_id").
+		Where("id = ?", postID).
+		Where("status = ?", model.PostStatusPublished).
+		Scan(ctx)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении комментариев"})
+	}
+	viewerID, _ := c.Get("user_id").(int)
+	visible, err := policy.CanViewPosts(ctx, h.DB, viewerID, post.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении комментариев"})
+	}
+	if !visible {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден"})
+	}
+
+	// Удалённый комментарий, у которого остались видимые ответы, отдаётся
+	// заглушкой, чтобы до ответов можно было дойти через ?parent_id=
+	visibleReplies := h.DB.NewSelect().
+		TableExpr("comments AS reply").
+		ColumnExpr("1").
+		Where("reply.parent_id = comment.id").
+		Where("reply.deleted_at IS NULL").
+		Where("reply.hidden_at IS NULL")
+
+	comments := make([]model.Comment, 0, limit+1)
+	query := h.DB.NewSelect().
+		Model(&comments).
+		WhereAllWithDeleted().
+		Relation("User").
+		Where("comment.post_id = ?", postID).
+		Where("comment.hidden_at IS NULL").
+		Where("comment.deleted_at IS NULL OR EXISTS (?)", visibleReplies).
+		Limit(limit + 1)
+
+	if p := c.QueryParam("parent_id"); p != "" {
+		parentID, err := strconv.Atoi(p)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный parent_id"})
+		}
+		// Ответы удалённого комментария остаются доступны
+		exists, err := h.DB.NewSelect().
+			Model((*model.Comment)(nil)).
+			WhereAllWithDeleted().
+			Where("id = ?", parentID).
+			Where("post_id = ?", postID).
+			Where("hidden_at IS NULL").
+			Exists(ctx)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении комментариев"})
+		}
+		if !exists {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Комментарий не найден"})
+		}
+		query = query.Where("comment.parent_id = ?", parentID)
+		post.PinnedCommentID = nil
+	} else {
+		query = query.Where("comment.parent_id IS NULL")
+	}
+	if post.PinnedCommentID != nil {
+		query = query.Where("comment.id <> ?", *post.PinnedCommentID)
+	}
+
+	switch sort {
+	case model.CommentSortNewest:
+		query = query.OrderExpr("comment.created_at DESC, comment.id DESC")
+		if cursor != nil {
+			query = query.Where("(comment.created_at, comment.id) < (?, ?)", time.UnixMicro(cursor.Key), cursor.ID)
+		}
+	case model.CommentSortOldest:
+		query = query.OrderExpr("comment.created_at ASC, comment.id ASC")
+		if cursor != nil {
+			query = query.Where("(comment.created_at, comment.id) > (?, ?)", time.UnixMicro(cursor.Key), cursor.ID)
+		}
+	case model.CommentSortTop:
+		query = query.OrderExpr("comment.likes_count DESC, comment.id DESC")
+		if cursor != nil {
+			query = query.Where("(comment.likes_count, comment.id) < (?, ?)", cursor.Key, cursor.ID)
+		}
+	}
+
+	if err := query.Scan(ctx); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении комментариев"})
 	}
 
-	return c.JSON(http.StatusOK, comments)
+	page := model.CommentPage{Comments: comments}
+	if len(comments) > limit {
+		page.Comments = comments[:limit]
+		last := page.Comments[limit-1]
+		next := commentCursor{Key: last.CreatedAt.UnixMicro(), ID: last.ID}
+		if sort == model.CommentSortTop {
+			next.Key = int64(last.LikesCount)
+		}
+		page.NextCursor = encodeCommentCursor(next)
+	}
+	if post.PinnedCommentID != nil && cursor == nil {
+		pinned := model.Comment{}
+		err := h.DB.NewSelect().
+			Model(&pinned).
+			Relation("User").
+			Where("comment.id = ?", *post.PinnedCommentID).
+			Where("comment.hidden_at IS NULL").
+			Scan(ctx)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении комментариев"})
+		}
+		if err == nil {
+			pinned.Pinned = true
+			page.Comments = append([]model.Comment{pinned}, page.Comments...)
+		}
+	}
+	setCommentAuthors(page.Comments)
+	setCommentTombstones(page.Comments)
+
+	return c.JSON(http.StatusOK, page)
 }
 
-// Удаление своих комментов
+// setCommentAuthors заполняет публичные профили авторов из загруженных User
+func setCommentAuthors(comments []model.Comment) {
+	for i := range comments {
+		if user := comments[i].User; user != nil {
+			author := user.Public()
+			comments[i].Author = &author
+		}
+	}
+}
+
+// Удалённые комментарии в выдаче остаются только как заглушки: без текста и автора
+func setCommentTombstones(comments []model.Comment) {
+	for i := range comments {
+		if comments[i].DeletedAt.IsZero() {
+			continue
+		}
+		comments[i] = model.Comment{
+			ID:           comments[i].ID,
+			PostID:       comments[i].PostID,
+			CreatedAt:    comments[i].CreatedAt,
+			ParentID:     comments[i].ParentID,
+			Depth:        comments[i].Depth,
+			RepliesCount: comments[i].RepliesCount,
+			Deleted:      true,
+		}
+	}
+}
+
+// Удаление комментария: своего или любого под своим постом
 func (h *PostHandler) DeleteComment(c echo.Context) error {
 	postID, err := strconv.Atoi(c.Param("post_id"))
 	if err != nil {
@@ -156,26 +416,46 @@ func (h *PostHandler) DeleteComment(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve comment"})
 	}
   
-	// Проверяем, что пользователь — автор комментария
+	// Удалить может автор комментария или автор поста
 	if comment.UserID != userID {
-		return c.JSON(http.StatusForbidden, map[string]string{"error": "You are not allowed to delete this comment"})
+		owner, err := isPostAuthor(ctx, h.DB, postID, userID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve post"})
+		}
+		if !owner {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "You are not allowed to delete this comment"})
+		}
 	}
   
-	// Удаляем комментарий
-	_, err = h.DB.NewDelete().Model(comment).Where("id = ?", commentID).Exec(ctx)
+	// Перемещаем комментарий в корзину удалившего и уменьшаем счётчик в одной транзакции.
+	// Счётчик меняется, только если строку удалил именно этот запрос
+	err = h.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewUpdate().
+			Model((*model.Comment)(nil)).
+			Set("deleted_at = ?", time.Now()).
+			Set("deleted_by = ?", userID).
+			Where("id = ?", commentID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if deleted, _ := res.RowsAffected(); deleted == 0 {
+			return nil
+		}
+		if err := adjustRepliesCount(ctx, tx, comment.ParentID, -1); err != nil {
+			return err
+		}
+		_, err = tx.NewUpdate().
+			Model((*model.Post)(nil)).
+			WhereAllWithDeleted().
+			Set("comments_count = GREATEST(comments_count - 1, 0)").
+			Where("id = ?", postID).
+			Exec(ctx)
+		return err
+	})
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete comment"})
 	}
   
-	// Обновляем счётчик комментариев
-	_, err = h.DB.NewUpdate().
-		Model((*model.Post)(nil)).
-		Set("comments_count = comments_count - 1").
-		Where("id = ?", postID).
-		Exec(ctx)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update comments count"})
-	}
-  
 	return c.JSON(http.StatusOK, map[string]string{"message": "Comment deleted successfully"})
 }
\ No newline at end of file
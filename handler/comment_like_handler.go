@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"api-service/model"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+// Размер страницы списка лайкнувших комментарий
+const (
+	defaultCommentLikesPageSize = 50
+	maxCommentLikesPageSize     = 200
+)
+
+// commentExists сообщает, есть ли комментарий и опубликован ли его пост.
+// Удалённые в корзину и скрытые автором поста комментарии не учитываются
+func commentExists(ctx context.Context, db bun.IDB, commentID int) (bool, error) {
+	return db.NewSelect().
+		Model((*model.Comment)(nil)).
+		Join("JOIN posts AS p ON p.id = comment.post_id").
+		Where("comment.id = ?", commentID).
+		Where("comment.hidden_at IS NULL").
+		Where("p.status = ?", model.PostStatusPublished).
+		Where("p.deleted_at IS NULL").
+		Exists(ctx)
+}
+
+// Лайк комментария: повторный запрос снимает лайк
+func (h *PostHandler) LikeComment(c echo.Context) error {
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID комментария"})
+	}
+	userID := c.Get("user_id").(int)
+
+	// Лайк и счётчик меняются в одной транзакции; уникальная пара (comment_id, user_id)
+	// не даёт параллельным запросам вставить лайк дважды
+	liked := false
+	err = h.DB.RunInTx(c.Request().Context(), nil, func(ctx context.Context, tx bun.Tx) error {
+		exists, err := commentExists(ctx, tx, commentID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return sql.ErrNoRows
+		}
+
+		like := &model.CommentLike{CommentID: commentID, UserID: userID}
+		res, err := tx.NewInsert().
+			Model(like).
+			On("CONFLICT (comment_id, user_id) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		delta := 1
+		if inserted, _ := res.RowsAffected(); inserted > 0 {
+			liked = true
+		} else {
+			// Лайк уже есть — удаляем; счётчик уменьшаем, только если удалили именно мы
+			res, err = tx.NewDelete().
+				Model((*model.CommentLike)(nil)).
+				Where("comment_id = ? AND user_id = ?", commentID, userID).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+			if deleted, _ := res.RowsAffected(); deleted == 0 {
+				return nil
+			}
+			delta = -1
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*model.Comment)(nil)).
+			WhereAllWithDeleted().
+			Set("likes_count = GREATEST(likes_count + ?, 0)", delta).
+			Where("id = ?", commentID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || isPgError(err, pgForeignKeyViolation) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Комментарий не найден"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при обновлении лайка"})
+	}
+
+	if liked {
+		return c.JSON(http.StatusOK, map[string]string{"message": "Лайк добавлен"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Лайк удалён"})
+}
+
+// Кто лайкнул комментарий, последние первыми: ?limit=, ?before= (ID лайка)
+func (h *PostHandler) GetCommentLikes(c echo.Context) error {
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID комментария"})
+	}
+	limit := defaultCommentLikesPageSize
+	if l := c.QueryParam("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > maxCommentLikesPageSize {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный limit"})
+		}
+		limit = n
+	}
+	before := 0
+	if b := c.QueryParam("before"); b != "" {
+		if before, err = strconv.Atoi(b); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный before"})
+		}
+	}
+	ctx := c.Request().Context()
+
+	exists, err := commentExists(ctx, h.DB, commentID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении лайков"})
+	}
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Комментарий не найден"})
+	}
+
+	var likes []model.CommentLike
+	query := h.DB.NewSelect().
+		Model(&likes).
+		Relation("User").
+		Where("comment_like.comment_id = ?", commentID).
+		OrderExpr("comment_like.id DESC").
+		Limit(limit)
+	if before != 0 {
+		query = query.Where("comment_like.id < ?", before)
+	}
+	if err := query.Scan(ctx); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении лайков"})
+	}
+
+	likers := make([]model.CommentLiker, 0, len(likes))
+	for _, like := range likes {
+		if like.User == nil {
+			continue
+		}
+		likers = append(likers, model.CommentLiker{User: like.User.Public(), LikedAt: like.CreatedAt})
+	}
+
+	response := struct {
+		Likes      []model.CommentLiker `json:"likes"`
+		NextBefore int                  `json:"next_before,omitempty"` // Значение ?before= для следующей страницы
+	}{Likes: likers}
+	if len(likes) == limit {
+		response.NextBefore = likes[len(likes)-1].ID
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,327 @@
+package handler
+
+import (
+	"api-service/model"
+	"api-service/policy"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+// errCommentsRestricted — автор поста не разрешил этому пользователю комментировать
+var errCommentsRestricted = errors.New("comments are restricted by the post author")
+
+func validCommentsPolicy(p string) bool {
+	switch p {
+	case model.CommentsPolicyEveryone, model.CommentsPolicyFollowers,
+		model.CommentsPolicyMentioned, model.CommentsPolicyNobody:
+		return true
+	}
+	return false
+}
+
+// resolveCommentsPolicy проверяет настройку комментариев нового поста; пустая — everyone
+func resolveCommentsPolicy(p string) (string, error) {
+	if p == "" {
+		return model.CommentsPolicyEveryone, nil
+	}
+	if !validCommentsPolicy(p) {
+		return "", fmt.Errorf("comments_policy must be one of %s, %s, %s, %s",
+			model.CommentsPolicyEveryone, model.CommentsPolicyFollowers,
+			model.CommentsPolicyMentioned, model.CommentsPolicyNobody)
+	}
+	return p, nil
+}
+
+// checkCanComment проверяет, что пост виден пользователю, и настройку комментариев
+// опубликованного поста, как checkReplyTarget. Возвращает sql.ErrNoRows, если поста
+// нет или он скрыт от пользователя, и errCommentsRestricted, если комментировать нельзя
+func checkCanComment(ctx context.Context, db bun.IDB, userID, postID int) error {
+	post := new(model.Post)
+	err := db.NewSelect().
+		Model(post).
+		Column("id", "user_id", "comments_policy").
+		Where("id = ?", postID).
+		Where("status = ?", model.PostStatusPublished).
+		Scan(ctx)
+	if err != nil {
+		return err
+	}
+
+	visible, err := policy.CanViewPosts(ctx, db, userID, post.UserID)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return sql.ErrNoRows
+	}
+
+	allowed, err := policy.CanComment(ctx, db, userID, post)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errCommentsRestricted
+	}
+	return nil
+}
+
+// isPostAuthor сообщает, что postID — неудалённый пост пользователя userID
+func isPostAuthor(ctx context.Context, db bun.IDB, postID, userID int) (bool, error) {
+	return db.NewSelect().
+		Model((*model.Post)(nil)).
+		Where("id = ?", postID).
+		Where("user_id = ?", userID).
+		Exists(ctx)
+}
+
+// Кто может комментировать пост: everyone, followers, mentioned или nobody
+func (h *PostHandler) SetCommentsPolicy(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID поста"})
+	}
+	userID := c.Get("user_id").(int)
+
+	req := new(struct {
+		CommentsPolicy string `json:"comments_policy"`
+	})
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный запрос"})
+	}
+	if !validCommentsPolicy(req.CommentsPolicy) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректная настройка комментариев"})
+	}
+
+	res, err := h.DB.NewUpdate().
+		Model((*model.Post)(nil)).
+		Set("comments_policy = ?", req.CommentsPolicy).
+		Where("id = ?", postID).
+		Where("user_id = ?", userID).
+		Exec(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при обновлении настроек комментариев"})
+	}
+	if updated, _ := res.RowsAffected(); updated == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден или нет доступа"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Настройки комментариев обновлены"})
+}
+
+// Закрепление комментария над остальными. Закрепить можно только видимый
+// комментарий к самому посту, не ответ; новый закреп заменяет прежний
+func (h *PostHandler) PinComment(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID поста"})
+	}
+	userID := c.Get("user_id").(int)
+
+	req := new(struct {
+		CommentID int `json:"comment_id"`
+	})
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный запрос"})
+	}
+	ctx := c.Request().Context()
+
+	owner, err := isPostAuthor(ctx, h.DB, postID, userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при закреплении комментария"})
+	}
+	if !owner {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден или нет доступа"})
+	}
+
+	comment := new(model.Comment)
+	err = h.DB.NewSelect().
+		Model(comment).
+		Column("id", "parent_id").
+		Where("id = ?", req.CommentID).
+		Where("post_id = ?", postID).
+		Where("hidden_at IS NULL").
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Комментарий не найден"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при закреплении комментария"})
+	}
+	if comment.ParentID != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Закрепить можно только комментарий к посту, а не ответ"})
+	}
+
+	_, err = h.DB.NewUpdate().
+		Model((*model.Post)(nil)).
+		Set("pinned_comment_id = ?", comment.ID).
+		Where("id = ?", postID).
+		Exec(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при закреплении комментария"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Комментарий закреплён"})
+}
+
+// Снятие закреплённого комментария
+func (h *PostHandler) UnpinComment(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID поста"})
+	}
+	userID := c.Get("user_id").(int)
+
+	res, err := h.DB.NewUpdate().
+		Model((*model.Post)(nil)).
+		Set("pinned_comment_id = NULL").
+		Where("id = ?", postID).
+		Where("user_id = ?", userID).
+		Exec(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при откреплении комментария"})
+	}
+	if updated, _ := res.RowsAffected(); updated == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден или нет доступа"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Комментарий откреплён"})
+}
+
+// Скрытие комментария автором поста. Скрытый комментарий пропадает из выдачи
+// и снимается с закрепа, но остаётся в счётчиках и виден автору поста в
+// GET /posts/:id/comments/hidden; ответы на него тоже не показываются
+func (h *PostHandler) HideComment(c echo.Context) error {
+	return h.setCommentHidden(c, true)
+}
+
+// Возврат скрытого комментария в выдачу
+func (h *PostHandler) UnhideComment(c echo.Context) error {
+	return h.setCommentHidden(c, false)
+}
+
+func (h *PostHandler) setCommentHidden(c echo.Context, hidden bool) error {
+	postID, err := strconv.Atoi(c.Param("post_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID поста"})
+	}
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID комментария"})
+	}
+	userID := c.Get("user_id").(int)
+
+	err = h.DB.RunInTx(c.Request().Context(), nil, func(ctx context.Context, tx bun.Tx) error {
+		owner, err := isPostAuthor(ctx, tx, postID, userID)
+		if err != nil {
+			return err
+		}
+		if !owner {
+			return sql.ErrNoRows
+		}
+
+		query := tx.NewUpdate().
+			Model((*model.Comment)(nil)).
+			Where("id = ?", commentID).
+			Where("post_id = ?", postID)
+		if hidden {
+			query = query.Set("hidden_at = ?", time.Now())
+		} else {
+			query = query.Set("hidden_at = NULL")
+		}
+		res, err := query.Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if updated, _ := res.RowsAffected(); updated == 0 {
+			return sql.ErrNoRows
+		}
+		if !hidden {
+			return nil
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*model.Post)(nil)).
+			Set("pinned_comment_id = NULL").
+			Where("id = ?", postID).
+			Where("pinned_comment_id = ?", commentID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Комментарий не найден или нет доступа"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при обновлении комментария"})
+	}
+
+	if hidden {
+		return c.JSON(http.StatusOK, map[string]string{"message": "Комментарий скрыт"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Комментарий снова виден"})
+}
+
+// Скрытые комментарии своего поста, последние первыми: ?limit=, ?before= (ID комментария)
+func (h *PostHandler) GetHiddenComments(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID поста"})
+	}
+	userID := c.Get("user_id").(int)
+	limit := defaultCommentsPageSize
+	if l := c.QueryParam("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > maxCommentsPageSize {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный limit"})
+		}
+		limit = n
+	}
+	before := 0
+	if b := c.QueryParam("before"); b != "" {
+		if before, err = strconv.Atoi(b); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный before"})
+		}
+	}
+	ctx := c.Request().Context()
+
+	owner, err := isPostAuthor(ctx, h.DB, postID, userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении комментариев"})
+	}
+	if !owner {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден или нет доступа"})
+	}
+
+	comments := make([]model.Comment, 0, limit)
+	query := h.DB.NewSelect().
+		Model(&comments).
+		Relation("User").
+		Where("comment.post_id = ?", postID).
+		Where("comment.hidden_at IS NOT NULL").
+		OrderExpr("comment.id DESC").
+		Limit(limit)
+	if before != 0 {
+		query = query.Where("comment.id < ?", before)
+	}
+	if err := query.Scan(ctx); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении комментариев"})
+	}
+	setCommentAuthors(comments)
+
+	response := struct {
+		Comments   []model.Comment `json:"comments"`
+		NextBefore int             `json:"next_before,omitempty"` // Значение ?before= для следующей страницы
+	}{Comments: comments}
+	if len(comments) == limit {
+		response.NextBefore = comments[len(comments)-1].ID
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
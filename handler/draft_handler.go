@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"api-service/model"
+	"api-service/publishing"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+// postStatusError — недопустимый статус или время публикации, текст отдаётся клиенту
+type postStatusError struct {
+	msg string
+}
+
+func (e *postStatusError) Error() string { return e.msg }
+
+// resolvePostStatus проверяет статус и время публикации. Пустой статус означает
+// немедленную публикацию, а вместе с publishAt — отложенную
+func resolvePostStatus(status string, publishAt *time.Time) (string, error) {
+	if status == "" {
+		status = model.PostStatusPublished
+		if publishAt != nil {
+			status = model.PostStatusScheduled
+		}
+	}
+
+	switch status {
+	case model.PostStatusScheduled:
+		if publishAt == nil {
+			return "", &postStatusError{"publish_at is required for scheduled posts"}
+		}
+		if !publishAt.After(time.Now()) {
+			return "", &postStatusError{"publish_at must be in the future"}
+		}
+	case model.PostStatusDraft, model.PostStatusPublished:
+		if publishAt != nil {
+			return "", &postStatusError{fmt.Sprintf("publish_at is only allowed for %s posts", model.PostStatusScheduled)}
+		}
+	default:
+		return "", &postStatusError{fmt.Sprintf("status must be %q, %q or %q",
+			model.PostStatusDraft, model.PostStatusScheduled, model.PostStatusPublished)}
+	}
+	return status, nil
+}
+
+// setPostStatus сохраняет новый статус поста и ставит в очередь его публикацию.
+// Опубликованный пост нельзя вернуть в черновики или отложить
+func setPostStatus(ctx context.Context, tx bun.Tx, post *model.Post, status *string, publishAt *time.Time) error {
+	next := post.Status
+	switch {
+	case status != nil:
+		next = *status
+	case publishAt != nil:
+		next = model.PostStatusScheduled
+	}
+	if post.Status == model.PostStatusPublished {
+		if next != model.PostStatusPublished || publishAt != nil {
+			return &postStatusError{"published post cannot be unpublished or rescheduled"}
+		}
+		return nil
+	}
+	// Перенос из scheduled в scheduled без нового времени оставляет прежнее
+	if next == model.PostStatusScheduled && publishAt == nil && post.Status == model.PostStatusScheduled {
+		publishAt = post.PublishAt
+	}
+
+	next, err := resolvePostStatus(next, publishAt)
+	if err != nil {
+		return err
+	}
+
+	query := tx.NewUpdate().
+		Model((*model.Post)(nil)).
+		Set("status = ?", next).
+		Set("publish_at = ?", publishAt).
+		Where("id = ?", post.ID)
+	if next == model.PostStatusPublished {
+		query = query.Set("published_at = ?", time.Now())
+	}
+	if _, err := query.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update post status: %w", err)
+	}
+
+	switch next {
+	case model.PostStatusScheduled:
+		return publishing.Schedule(ctx, tx, post.ID, *publishAt)
+	case model.PostStatusPublished:
+		return publishing.Published(ctx, tx, post.ID)
+	}
+	return nil
+}
+
+// listOwnPosts отдаёт посты текущего пользователя с указанным статусом
+func (h *PostHandler) listOwnPosts(c echo.Context, status, order string) error {
+	userID := c.Get("user_id").(int)
+
+	posts := make([]model.Post, 0)
+	err := h.DB.NewSelect().
+		Model(&posts).
+		Relation("Tags").
+		Relation("Media").
+		Where("post.user_id = ?", userID).
+		Where("post.status = ?", status).
+		OrderExpr(order).
+		Scan(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve posts"})
+	}
+	if err := attachQuotes(c.Request().Context(), h.DB, userID, postRefs(posts)...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve quoted posts"})
+	}
+	return c.JSON(http.StatusOK, posts)
+}
+
+// Черновики текущего пользователя, последние изменённые первыми
+func (h *PostHandler) GetDrafts(c echo.Context) error {
+	return h.listOwnPosts(c, model.PostStatusDraft, "COALESCE(post.edited_at, post.created_at) DESC")
+}
+
+// Отложенные посты текущего пользователя в порядке публикации
+func (h *PostHandler) GetScheduled(c echo.Context) error {
+	return h.listOwnPosts(c, model.PostStatusScheduled, "post.publish_at ASC")
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"api-service/model"
+	"api-service/publishing"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+func TestResolvePostStatus(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		name      string
+		status    string
+		publishAt *time.Time
+		want      string // Пусто — ожидается ошибка
+	}{
+		{"по умолчанию публикуется сразу", "", nil, model.PostStatusPublished},
+		{"время без статуса откладывает", "", &future, model.PostStatusScheduled},
+		{"черновик", model.PostStatusDraft, nil, model.PostStatusDraft},
+		{"отложенный", model.PostStatusScheduled, &future, model.PostStatusScheduled},
+		{"отложенный без времени", model.PostStatusScheduled, nil, ""},
+		{"отложенный в прошлое", model.PostStatusScheduled, &past, ""},
+		{"черновик со временем", model.PostStatusDraft, &future, ""},
+		{"опубликованный со временем", model.PostStatusPublished, &future, ""},
+		{"неизвестный статус", "archived", nil, ""},
+	}
+	for _, tc := range cases {
+		got, err := resolvePostStatus(tc.status, tc.publishAt)
+		if tc.want == "" {
+			var statusErr *postStatusError
+			if !errors.As(err, &statusErr) {
+				t.Errorf("%s: ожидалась postStatusError, получено %q, %v", tc.name, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("%s: получено %q, %v; ожидалось %q", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+// jobKeys возвращает ключи задач поста в очереди
+func jobKeys(t *testing.T, conn bun.IDB, kind string) []string {
+	t.Helper()
+	keys := make([]string, 0)
+	err := conn.NewSelect().
+		Model((*model.Job)(nil)).
+		Column("unique_key").
+		Where("kind = ?", kind).
+		Order("id").
+		Scan(context.Background(), &keys)
+	if err != nil {
+		t.Fatalf("загрузка задач %s: %v", kind, err)
+	}
+	return keys
+}
+
+func TestSetPostStatusTransitions(t *testing.T) {
+	conn := newTestDB(t)
+	userID := createTestUser(t, conn, "author")
+
+	setStatus := func(post *model.Post, status *string, publishAt *time.Time) error {
+		return conn.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+			return setPostStatus(ctx, tx, post, status, publishAt)
+		})
+	}
+	str := func(s string) *string { return &s }
+
+	// Черновик → отложенный: ставится задача публикации на publish_at
+	draft := createTestPost(t, conn, userID, model.PostStatusDraft, nil, nil, nil)
+	at := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := setStatus(draft, nil, &at); err != nil {
+		t.Fatalf("draft → scheduled: %v", err)
+	}
+	scheduled := loadTestPost(t, conn, draft.ID)
+	if scheduled.Status != model.PostStatusScheduled || scheduled.PublishAt == nil || !scheduled.PublishAt.Equal(at) {
+		t.Fatalf("draft → scheduled: %+v", scheduled)
+	}
+	if keys := jobKeys(t, conn, publishing.PublishJobKind); len(keys) != 1 {
+		t.Fatalf("задачи публикации: %v, ожидалась одна", keys)
+	}
+
+	// Отложенный → отложенный без нового времени сохраняет прежнее
+	if err := setStatus(scheduled, str(model.PostStatusScheduled), nil); err != nil {
+		t.Fatalf("scheduled → scheduled: %v", err)
+	}
+	if got := loadTestPost(t, conn, draft.ID); got.PublishAt == nil || !got.PublishAt.Equal(at) {
+		t.Fatalf("время публикации изменилось: %v", got.PublishAt)
+	}
+
+	// Отложенный → опубликованный: published_at выставлен, время снято
+	if err := setStatus(scheduled, str(model.PostStatusPublished), nil); err != nil {
+		t.Fatalf("scheduled → published: %v", err)
+	}
+	published := loadTestPost(t, conn, draft.ID)
+	if published.Status != model.PostStatusPublished || published.PublishedAt == nil || published.PublishAt != nil {
+		t.Fatalf("scheduled → published: %+v", published)
+	}
+	if keys := jobKeys(t, conn, publishing.PublishedJobKind); len(keys) != 1 {
+		t.Fatalf("задачи о публикации: %v, ожидалась одна", keys)
+	}
+
+	// Опубликованный пост нельзя вернуть в черновики или отложить
+	for _, tc := range []struct {
+		status    *string
+		publishAt *time.Time
+	}{
+		{str(model.PostStatusDraft), nil},
+		{nil, &at},
+	} {
+		var statusErr *postStatusError
+		if err := setStatus(published, tc.status, tc.publishAt); !errors.As(err, &statusErr) {
+			t.Fatalf("published → %v/%v: ожидалась postStatusError, получено %v", tc.status, tc.publishAt, err)
+		}
+	}
+	// Повторная публикация ничего не меняет
+	if err := setStatus(published, str(model.PostStatusPublished), nil); err != nil {
+		t.Fatalf("published → published: %v", err)
+	}
+	if keys := jobKeys(t, conn, publishing.PublishedJobKind); len(keys) != 1 {
+		t.Fatalf("повторная публикация поставила задачу: %v", keys)
+	}
+}
@@ -2,15 +2,18 @@ package handler
 
 import (
 	"api-service/model"
+	"context"
 	"database/sql"
 	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
 )
 
-// Добавление лайка к посту
+// Лайк поста — реакция like: повторный запрос снимает её, а другая реакция
+// заменяется на like
 func (h *PostHandler) LikePost(c echo.Context) error {
 	// Получаем ID поста
 	postIDParam := c.Param("id")
@@ -21,74 +24,29 @@ func (h *PostHandler) LikePost(c echo.Context) error {
 
 	// Получаем ID пользователя
 	userID := c.Get("user_id").(int)
-	ctx := c.Request().Context()
 
-	// Начинаем транзакцию
-	tx, err := h.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка начала транзакции"})
-	}
-	defer func() {
+	liked := false
+	err = h.DB.RunInTx(c.Request().Context(), nil, func(ctx context.Context, tx bun.Tx) error {
+		old, err := setReaction(ctx, tx, postID, userID, model.ReactionLike)
 		if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
+			return err
 		}
-	}()
-
-	// Проверяем, существует ли лайк
-	existingLike := new(model.PostLike)
-	err = tx.NewSelect().
-		Model(existingLike).
-		Where("post_id = ? AND user_id = ?", postID, userID).
-		Scan(ctx)
-
-	if err == nil { // Лайк уже есть — удаляем
-		_, err = tx.NewDelete().
-			Model((*model.PostLike)(nil)).
-			Where("post_id = ? AND user_id = ?", postID, userID).
-			Exec(ctx)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при удалении лайка"})
+		if old != model.ReactionLike {
+			liked = true
+			return nil
 		}
-
-		// Уменьшаем счетчик
-		_, err = tx.NewUpdate().
-			Model((*model.Post)(nil)).
-			Set("likes_count = GREATEST(likes_count - 1, 0)").
-			Where("id = ?", postID).
-			Exec(ctx)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при обновлении счетчика лайков"})
-		}
-
-		return c.JSON(http.StatusOK, map[string]string{"message": "Лайк удалён"})
-	}
-
-	if !errors.Is(err, sql.ErrNoRows) { // Неизвестная ошибка
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при проверке лайка"})
-	}
-
-	// Лайка нет — добавляем
-	like := &model.PostLike{
-		PostID: postID,
-		UserID: userID,
-	}
-	_, err = tx.NewInsert().Model(like).Exec(ctx)
+		_, err = setReaction(ctx, tx, postID, userID, "")
+		return err
+	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при добавлении лайка"})
+		if errors.Is(err, sql.ErrNoRows) || isPgError(err, pgForeignKeyViolation) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при обновлении лайка"})
 	}
 
-	// Увеличиваем счетчик
-	_, err = tx.NewUpdate().
-		Model((*model.Post)(nil)).
-		Set("likes_count = likes_count + 1").
-		Where("id = ?", postID).
-		Exec(ctx)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при обновлении счетчика лайков"})
+	if liked {
+		return c.JSON(http.StatusOK, map[string]string{"message": "Лайк добавлен"})
 	}
-
-	return c.JSON(http.StatusOK, map[string]string{"message": "Лайк добавлен"})
+	return c.JSON(http.StatusOK, map[string]string{"message": "Лайк удалён"})
 }
-			
\ No newline at end of file
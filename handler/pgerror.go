@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// Код ошибки PostgreSQL: нарушение внешнего ключа
+const pgForeignKeyViolation = "23503"
+
+// isPgError сообщает, что err — ошибка PostgreSQL с указанным кодом
+func isPgError(err error, code string) bool {
+	var pgErr pgdriver.Error
+	return errors.As(err, &pgErr) && pgErr.Field('C') == code
+}
@@ -2,19 +2,37 @@ package handler
 
 import (
 	"api-service/model"
+	"api-service/policy"
+	"api-service/publishing"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/uptrace/bun"
+
+	authpb "api-service/proto/auth-service/proto"
+	chatpb "api-service/proto/chat-service/proto"
 )
 
+// errPostForbidden — пост принадлежит другому пользователю
+var errPostForbidden = errors.New("post belongs to another user")
+
 type PostHandler struct {
-	DB *bun.DB
+	DB                *bun.DB
+	ChatServiceClient chatpb.ChatServiceClient // Для пересылки постов в чаты
+	AuthServiceClient authpb.AuthServiceClient // Политика взаимодействия при пересылке в личные чаты
+	EditWindow        time.Duration            // Сколько после публикации пост можно редактировать; 0 — без ограничения
+	TrashRetention    time.Duration            // Сколько удалённые посты и комментарии можно восстановить
+	CommentMaxDepth   int                      // Максимальная вложенность ответов на комментарии
+	Reactions         []string                 // Допустимые реакции на посты
 }
 
 // Хелпер для обработки ошибок базы данных
@@ -25,85 +43,196 @@ func (h *PostHandler) respondWithError(c echo.Context, status int, message strin
 	return c.JSON(status, map[string]string{"error": message})
 }
 
-// Хелпер для работы с тегами
-func (h *PostHandler) manageTags(ctx echo.Context, postID int, tags []string) error {
-    // Начинаем транзакцию
-    tx, err := h.DB.BeginTx(ctx.Request().Context(), nil)
-    if err != nil {
-        log.Printf("Ошибка при начале транзакции: %v", err)
-        return fmt.Errorf("failed to start transaction: %w", err)
-    }
-    defer tx.Rollback() // Откат транзакции в случае ошибки
-
-    for _, tagName := range tags {
-        tag := &model.Tag{}
-        err := tx.NewSelect().Model(tag).Where("name = ?", tagName).Scan(ctx.Request().Context())
-        if err != nil && !errors.Is(err, sql.ErrNoRows) {
-            log.Printf("Ошибка при проверке тега: %v", err)
-            return fmt.Errorf("failed to check tag: %w", err)
-        }
+// postExists сообщает, опубликован ли пост и не удалён ли он в корзину
+func postExists(ctx context.Context, db bun.IDB, postID int) (bool, error) {
+	return db.NewSelect().
+		Model((*model.Post)(nil)).
+		Where("id = ?", postID).
+		Where("status = ?", model.PostStatusPublished).
+		Exists(ctx)
+}
 
-        // Если тег не найден, создаем его
-        if errors.Is(err, sql.ErrNoRows) {
-            tag = &model.Tag{Name: tagName}
-            _, err := tx.NewInsert().Model(tag).Exec(ctx.Request().Context())
-            if err != nil {
-                log.Printf("Ошибка при создании тега: %v", err)
-                return fmt.Errorf("failed to create tag: %w", err)
-            }
-        }
+// Ограничения на медиа поста
+const (
+	maxPostMedia   = 10
+	maxMediaURLLen = 2048
+)
 
-        // Проверяем, существует ли уже связь между постом и тегом
-        exists, err := tx.NewSelect().
-            Model((*model.PostTag)(nil)).
-            Where("post_id = ? AND tag_id = ?", postID, tag.ID).
-            Limit(1).
-            Exists(ctx.Request().Context())
-        if err != nil {
-            log.Printf("Ошибка при проверке связи пост-тег: %v", err)
-            return fmt.Errorf("failed to check post-tag relationship: %w", err)
-        }
+// Сколько разных упоминаний поста сохраняется
+const maxPostMentions = 50
 
-        // Если связь не существует, создаем её
-        if !exists {
-            postTag := &model.PostTag{PostID: postID, TagID: tag.ID}
-            _, err = tx.NewInsert().Model(postTag).Exec(ctx.Request().Context())
-            if err != nil {
-                log.Printf("Ошибка при создании связи пост-тег: %v", err)
-                return fmt.Errorf("failed to link tag to post: %w", err)
-            }
-        }
-    }
+// validateMedia проверяет тип и ссылку каждого медиафайла.
+// Ошибка содержит понятное клиенту описание
+func validateMedia(media []model.Media) error {
+	if len(media) > maxPostMedia {
+		return fmt.Errorf("too many media items: at most %d allowed", maxPostMedia)
+	}
+	for i, item := range media {
+		if item.Type != model.MediaTypeImage && item.Type != model.MediaTypeVideo {
+			return fmt.Errorf("media[%d]: type must be %q or %q", i, model.MediaTypeImage, model.MediaTypeVideo)
+		}
+		if item.URL == "" || len(item.URL) > maxMediaURLLen {
+			return fmt.Errorf("media[%d]: url is required and must be at most %d characters", i, maxMediaURLLen)
+		}
+		u, err := url.ParseRequestURI(item.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("media[%d]: url must be an absolute http(s) URL", i)
+		}
+	}
+	return nil
+}
 
-    // Фиксируем транзакцию
-    if err := tx.Commit(); err != nil {
-        log.Printf("Ошибка при фиксации транзакции: %v", err)
-        return fmt.Errorf("failed to commit transaction: %w", err)
-    }
+// Хелпер для работы с тегами: привязывает теги к посту в транзакции вызывающего
+func manageTags(ctx context.Context, tx bun.IDB, postID int, tags []string) error {
+	for _, tagName := range tags {
+		tagName = strings.TrimSpace(tagName)
+		if tagName == "" {
+			continue
+		}
+
+		// Создаём тег или получаем ID существующего одним запросом,
+		// чтобы параллельные запросы с тем же тегом не конфликтовали
+		tag := &model.Tag{Name: tagName}
+		_, err := tx.NewInsert().
+			Model(tag).
+			On("CONFLICT (name) DO UPDATE").
+			Set("name = EXCLUDED.name").
+			Returning("id").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create tag: %w", err)
+		}
 
-    return nil
+		// Повторная привязка тега к посту игнорируется
+		postTag := &model.PostTag{PostID: postID, TagID: tag.ID}
+		_, err = tx.NewInsert().
+			Model(postTag).
+			On("CONFLICT (post_id, tag_id) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to link tag to post: %w", err)
+		}
+	}
+	return nil
 }
 
-// Хелпер для работы с медиа
-func (h *PostHandler) manageMedia(ctx echo.Context, postID int, media []model.Media) error {
-	for _, mediaItem := range media {
-		mediaFile := &model.Media{PostID: postID, URL: mediaItem.URL, Type: mediaItem.Type}
-		_, err := h.DB.NewInsert().Model(mediaFile).Exec(ctx.Request().Context())
+// Хелпер для работы с упоминаниями: заново разбирает @имя в заголовке и тексте
+// поста и сохраняет ID упомянутых. Имя, которое носят несколько пользователей,
+// никого не упоминает; учитываются первые maxPostMentions имён
+func manageMentions(ctx context.Context, tx bun.IDB, postID int) error {
+	post := new(model.Post)
+	err := tx.NewSelect().Model(post).WhereAllWithDeleted().Column("id", "title", "content").Where("id = ?", postID).Scan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load post for mentions: %w", err)
+	}
+
+	_, err = tx.NewDelete().Model((*model.PostMention)(nil)).Where("post_id = ?", postID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to clear mentions: %w", err)
+	}
+
+	names := policy.MentionedNames(post.Title + " " + post.Content)
+	if len(names) == 0 {
+		return nil
+	}
+	if len(names) > maxPostMentions {
+		names = names[:maxPostMentions]
+	}
+
+	var mentions []model.PostMention
+	err = tx.NewSelect().
+		Model((*model.User)(nil)).
+		ColumnExpr("? AS post_id", postID).
+		ColumnExpr("MIN(id) AS user_id").
+		Where("lower(name) IN (?)", bun.In(names)).
+		GroupExpr("lower(name)").
+		Having("COUNT(*) = 1").
+		Scan(ctx, &mentions)
+	if err != nil {
+		return fmt.Errorf("failed to resolve mentions: %w", err)
+	}
+	if len(mentions) == 0 {
+		return nil
+	}
+	if _, err := tx.NewInsert().Model(&mentions).On("CONFLICT DO NOTHING").Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save mentions: %w", err)
+	}
+	return nil
+}
+
+// Хелпер для работы с медиа: сохраняет уже проверенные медиафайлы поста одним запросом
+func manageMedia(ctx context.Context, tx bun.IDB, postID int, media []model.Media) ([]model.Media, error) {
+	if len(media) == 0 {
+		return []model.Media{}, nil
+	}
+	items := make([]model.Media, len(media))
+	for i, item := range media {
+		items[i] = model.Media{PostID: postID, URL: item.URL, Type: item.Type}
+	}
+	if _, err := tx.NewInsert().Model(&items).Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to add media: %w", err)
+	}
+	return items, nil
+}
+
+// newPost собирает новый пост с учётом статуса публикации
+func newPost(userID int, title, content, status string, publishAt *time.Time) *model.Post {
+	post := &model.Post{
+		Title:     title,
+		Content:   content,
+		UserID:    userID,
+		Status:    status,
+		PublishAt: publishAt,
+	}
+	if status == model.PostStatusPublished {
+		now := time.Now()
+		post.PublishedAt = &now
+	}
+	return post
+}
+
+// insertPost сохраняет пост с тегами и медиа, первую ревизию и задачу публикации.
+// Вызывается в транзакции: при любой ошибке не остаётся ни поста без тегов,
+// ни медиа без поста. Пост без родителя становится корнем своей ветки
+func insertPost(ctx context.Context, tx bun.Tx, post *model.Post, tags []string, media []model.Media) error {
+	if _, err := tx.NewInsert().Model(post).Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create post: %w", err)
+	}
+	if post.ConversationID == 0 {
+		post.ConversationID = post.ID
+		_, err := tx.NewUpdate().Model(post).Column("conversation_id").WherePK().Exec(ctx)
 		if err != nil {
-			return errors.New("failed to add media")
+			return fmt.Errorf("failed to set conversation: %w", err)
 		}
 	}
+	if err := manageTags(ctx, tx, post.ID, tags); err != nil {
+		return err
+	}
+	if err := manageMentions(ctx, tx, post.ID); err != nil {
+		return err
+	}
+	items, err := manageMedia(ctx, tx, post.ID, media)
+	if err != nil {
+		return err
+	}
+	post.Media = items
+	// Первая ревизия — исходная версия поста
+	if err := snapshotRevision(ctx, tx, post.ID, post.UserID, post.CreatedAt); err != nil {
+		return err
+	}
+
+	switch post.Status {
+	case model.PostStatusScheduled:
+		return publishing.Schedule(ctx, tx, post.ID, *post.PublishAt)
+	case model.PostStatusPublished:
+		return publishing.Published(ctx, tx, post.ID)
+	}
 	return nil
 }
 
 func (h *PostHandler) CreatePost(c echo.Context) error {
-    var request struct {
-        Title   string   `json:"title"`
-        Content string   `json:"content"`
-        Tags    []string `json:"tags"`
-    }
-
-    if err := c.Bind(&request); err != nil {
+    request := new(model.CreatePostRequest)
+    if err := c.Bind(request); err != nil {
         return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
     }
 
@@ -118,32 +247,61 @@ func (h *PostHandler) CreatePost(c echo.Context) error {
         return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user ID"})
     }
 
-    // Проверяем, существует ли пользователь
-    exists, err := h.DB.NewSelect().
-        Model((*model.User)(nil)).
-        Where("id = ?", userID).
-        Exists(c.Request().Context())
+    // Проверяем медиа и статус до начала транзакции
+    if err := validateMedia(request.Media); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    status, err := resolvePostStatus(request.Status, request.PublishAt)
     if err != nil {
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check user existence"})
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
     }
-    if !exists {
-        return c.JSON(http.StatusBadRequest, map[string]string{"error": "user does not exist"})
+    commentsPolicy, err := resolveCommentsPolicy(request.CommentsPolicy)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
     }
 
     // Создаем пост
-    post := &model.Post{
-        Title:   request.Title,
-        Content: request.Content,
-        UserID:  userID,
+    post := newPost(userID, request.Title, request.Content, status, request.PublishAt)
+    post.CommentsPolicy = commentsPolicy
+    if request.QuotedPostID != nil {
+        err := checkQuotable(c.Request().Context(), h.DB, userID, *request.QuotedPostID)
+        if errors.Is(err, errQuoteNotAllowed) {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": "quoted post not found"})
+        }
+        if err != nil {
+            log.Printf("Ошибка проверки цитируемого поста: %v", err)
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create post"})
+        }
+        post.IsQuote = true
+        post.QuotedPostID = request.QuotedPostID
+    }
+    if request.InReplyToID != nil {
+        parent, err := checkReplyTarget(c.Request().Context(), h.DB, userID, *request.InReplyToID)
+        if errors.Is(err, errReplyNotAllowed) {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": "reply target not found"})
+        }
+        if errors.Is(err, errCommentsRestricted) {
+            return c.JSON(http.StatusForbidden, map[string]string{"error": "replies are restricted by the post author"})
+        }
+        if err != nil {
+            log.Printf("Ошибка проверки поста, на который отвечают: %v", err)
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create post"})
+        }
+        setReplyTarget(post, parent)
     }
 
-    if _, err := h.DB.NewInsert().Model(post).Exec(c.Request().Context()); err != nil {
+    err = h.DB.RunInTx(c.Request().Context(), nil, func(ctx context.Context, tx bun.Tx) error {
+        return insertPost(ctx, tx, post, request.Tags, request.Media)
+    })
+    if err != nil {
+        if isPgError(err, pgForeignKeyViolation) {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": "user does not exist"})
+        }
+        log.Printf("Ошибка создания поста: %v", err)
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create post"})
     }
-
-    // Управляем тегами
-    if err := h.manageTags(c, post.ID, request.Tags); err != nil {
-        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    if err := attachQuotes(c.Request().Context(), h.DB, userID, post); err != nil {
+        log.Printf("Ошибка загрузки цитируемого поста: %v", err)
     }
 
     return c.JSON(http.StatusCreated, post)
@@ -165,51 +323,41 @@ func (h *PostHandler) UpdatePost(c echo.Context) error {
         return h.respondWithError(c, http.StatusInternalServerError, "Failed to get user ID", nil)
     }
 
-    // Получаем пост из базы данных
-    post := &model.Post{}
-    err = h.DB.NewSelect().Model(post).Where("id = ?", id).Scan(c.Request().Context())
-    if err != nil {
-        return h.respondWithError(c, http.StatusNotFound, "Post not found", err)
-    }
-
-    // Проверяем, что пользователь имеет право редактировать пост
-    if post.UserID != userID {
-        return h.respondWithError(c, http.StatusForbidden, "You cannot edit this post", nil)
-    }
-
     // Парсим запрос на обновление
     req := new(model.UpdatePostRequest)
     if err := c.Bind(req); err != nil {
         return h.respondWithError(c, http.StatusBadRequest, "Invalid request", err)
     }
-
-    // Обновляем пост
-    _, err = h.DB.NewUpdate().Model(&model.Post{ID: id}).
-        Set("title = ?", req.Title).
-        Set("content = ?", req.Content).
-        Where("id = ?", id).
-        Exec(c.Request().Context())
-    if err != nil {
-        return h.respondWithError(c, http.StatusInternalServerError, "Failed to update post", err)
-    }
-
-    // Удаляем старые теги
-    _, err = h.DB.NewDelete().
-        Model((*model.PostTag)(nil)).
-        Where("post_id = ?", id).
-        Exec(c.Request().Context())
-    if err != nil {
-        return h.respondWithError(c, http.StatusInternalServerError, "Failed to delete old tags", err)
+    if err := validateMedia(req.Media); err != nil {
+        return h.respondWithError(c, http.StatusBadRequest, err.Error(), nil)
     }
+    // Пост, теги и медиа обновляются в одной транзакции вместе с новой ревизией
+    err = h.editPost(c.Request().Context(), id, userID, c.Request().Header.Get("If-Match"),
+        func(ctx context.Context, tx bun.Tx, post *model.Post) error {
+            _, err := tx.NewUpdate().Model(&model.Post{ID: id}).
+                Set("title = ?", req.Title).
+                Set("content = ?", req.Content).
+                Where("id = ?", id).
+                Exec(ctx)
+            if err != nil {
+                return fmt.Errorf("failed to update post: %w", err)
+            }
 
-    // Управляем тегами
-    if err := h.manageTags(c, id, req.Tags); err != nil {
-        return h.respondWithError(c, http.StatusInternalServerError, "Failed to update tags", err)
-    }
+            // Теги заменяются целиком, только если переданы в запросе (пустой список удаляет все)
+            if req.Tags != nil {
+                if err := patchTags(ctx, tx, id, &model.TagsPatch{Set: &req.Tags}); err != nil {
+                    return err
+                }
+            }
 
-    // Управляем медиа
-    if err := h.manageMedia(c, id, req.Media); err != nil {
-        return h.respondWithError(c, http.StatusInternalServerError, "Failed to update media", err)
+            // Медиа заменяются, только если переданы в запросе (пустой список удаляет все)
+            if req.Media == nil {
+                return nil
+            }
+            return patchMedia(ctx, tx, id, &model.MediaPatch{Set: &req.Media})
+        })
+    if err != nil {
+        return h.editErrorResponse(c, id, err)
     }
 
     // Возвращаем успешный ответ
@@ -218,14 +366,23 @@ func (h *PostHandler) UpdatePost(c echo.Context) error {
 
 // Получение списка постов
 func (h *PostHandler) GetPosts(c echo.Context) error {
+	viewerID, _ := c.Get("user_id").(int)
 	posts := make([]model.Post, 0)
 	err := h.DB.NewSelect().Model(&posts).
 	  Relation("Tags").
 	  Relation("Media").
+	  Where("post.status = ?", model.PostStatusPublished).
+	  Apply(policy.WherePostsVisible(viewerID, "post.user_id")). // Закрытые и заблокировавшие авторы не попадают в ленту
 	  Scan(c.Request().Context())
 	if err != nil {
 	  return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении постов"})
 	}
+	if err := attachQuotes(c.Request().Context(), h.DB, viewerID, postRefs(posts)...); err != nil {
+	  return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении цитируемых постов"})
+	}
+	if err := attachReactions(c.Request().Context(), h.DB, postRefs(posts)...); err != nil {
+	  return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении реакций"})
+	}
   
 	return c.JSON(http.StatusOK, posts)
 }
@@ -237,15 +394,35 @@ func (h *PostHandler) GetPostByID(c echo.Context) error {
   
 	err := h.DB.NewSelect().
 	  Model(post).
-	  Relation("Comments").
+	  Relation("Comments", func(q *bun.SelectQuery) *bun.SelectQuery {
+	    return q.Where("comment.hidden_at IS NULL") // Скрытые автором поста комментарии не показываются
+	  }).
 	  Relation("Tags").
 	  Relation("Media").
 	  Where("post.id = ?", id).
+	  Where("post.status = ?", model.PostStatusPublished). // Черновики автор получает через /me/drafts
 	  Scan(c.Request().Context())
 	if err != nil {
 	  return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден"})
 	}
+	// Скрытый от читателя пост неотличим от несуществующего
+	viewerID, _ := c.Get("user_id").(int)
+	visible, err := policy.CanViewPosts(c.Request().Context(), h.DB, viewerID, post.UserID)
+	if err != nil {
+	  return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при проверке доступа к посту"})
+	}
+	if !visible {
+	  return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден"})
+	}
+	if err := attachQuotes(c.Request().Context(), h.DB, viewerID, post); err != nil {
+	  return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении цитируемого поста"})
+	}
+	if err := attachReactions(c.Request().Context(), h.DB, post); err != nil {
+	  return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении реакций"})
+	}
   
+	// ETag передаётся обратно в If-Match при редактировании
+	c.Response().Header().Set("ETag", postETag(post.Version))
 	return c.JSON(http.StatusOK, post)
 }
 
@@ -256,21 +433,35 @@ func (h *PostHandler) GetUserPosts(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
 	}
 
+	viewerID, _ := c.Get("user_id").(int)
+
 	var posts []model.Post
 	err = h.DB.NewSelect().
 		Model(&posts).
 		Where("user_id = ?", userID).
-		Order("created_at DESC").
+		Where("status = ?", model.PostStatusPublished).
+		Apply(policy.WherePostsVisible(viewerID, "post.user_id")).
+		Order("published_at DESC").
 		Scan(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve posts"})
 	}
+	if err := attachQuotes(c.Request().Context(), h.DB, viewerID, postRefs(posts)...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve quoted posts"})
+	}
+	if err := attachReactions(c.Request().Context(), h.DB, postRefs(posts)...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve reactions"})
+	}
 
 	var reposts []model.Repost
 	err = h.DB.NewSelect().
 		Model(&reposts).
 		Relation("OriginalPost").
 		Where(`"repost"."user_id" = ?`, userID).
+		Where("original_post.id IS NOT NULL"). // Репосты удалённых постов скрываются
+		Where("original_post.status = ?", model.PostStatusPublished).
+		Apply(policy.WherePostsVisible(viewerID, "repost.user_id")).
+		Apply(policy.WherePostsVisible(viewerID, "original_post.user_id")).
 		Order("repost.created_at DESC").
 		Scan(c.Request().Context())
 	if err != nil {
@@ -296,57 +487,32 @@ func (h *PostHandler) DeletePost(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
 	}
   
-	// Проверяем, что пост принадлежит текущему пользователю
-	post := &model.Post{}
-	err = h.DB.NewSelect().
-		Model(post).
-		Where("id = ?", postID).
-		Where("user_id = ?", userID).
-		Scan(c.Request().Context())
+	// Перемещаем пост в корзину одним запросом, только если он принадлежит текущему
+	// пользователю. Связанные данные остаются, чтобы пост можно было восстановить;
+	// окончательно они удаляются каскадно вместе с постом при очистке корзины.
+	// Удалённая цитата перестаёт учитываться в счётчике оригинала
+	err = h.DB.RunInTx(c.Request().Context(), nil, func(ctx context.Context, tx bun.Tx) error {
+		post := new(model.Post)
+		res, err := tx.NewDelete().
+			Model(post).
+			Where("id = ?", postID).
+			Where("user_id = ?", userID).
+			Returning("quoted_post_id, status").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if deleted, _ := res.RowsAffected(); deleted == 0 {
+			return sql.ErrNoRows
+		}
+		return adjustQuotesCount(ctx, tx, post, -1)
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found or access denied"})
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve post"})
-	}
-  
-	ctx := c.Request().Context()
-  
-	// Удаляем комментарии, связанные с постом
-	_, err = h.DB.NewDelete().
-		Model((*model.Comment)(nil)).
-		Where("post_id = ?", postID).
-		Exec(ctx)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete comments"})
-	}
-  
-	// Удаляем репосты, связанные с постом
-	_, err = h.DB.NewDelete().
-		Model((*model.Repost)(nil)).
-		Where("original_post_id = ?", postID).
-		Exec(ctx)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete reposts"})
-	}
-  
-	// Удаляем лайки, связанные с постом
-	_, err = h.DB.NewDelete().
-		Model((*model.PostLike)(nil)).
-		Where("post_id = ?", postID).
-		Exec(ctx)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete likes"})
-	}
-  
-	// Удаляем сам пост
-	_, err = h.DB.NewDelete().
-		Model(post).
-		Where("id = ?", postID).
-		Exec(ctx)
-	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete post"})
 	}
   
-	return c.JSON(http.StatusOK, map[string]string{"message": "Post and related data deleted successfully"})
+	return c.JSON(http.StatusOK, map[string]string{"message": "Post moved to trash"})
 }
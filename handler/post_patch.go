@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"api-service/model"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+// errPostVersionMismatch — пост изменён после того, как клиент получил его ETag
+var errPostVersionMismatch = errors.New("post version mismatch")
+
+// postETag формирует ETag по версии поста
+func postETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchAllows проверяет заголовок If-Match против текущего ETag.
+// Пустой заголовок означает, что клиент не просит проверки
+func ifMatchAllows(header, etag string) bool {
+	if header == "" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePatch проверяет запрос PATCH до начала транзакции
+func validatePatch(req *model.PatchPostRequest) error {
+	if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
+		return errors.New("title must not be empty")
+	}
+	if req.Tags != nil && req.Tags.Set != nil && (len(req.Tags.Add) > 0 || len(req.Tags.Remove) > 0) {
+		return errors.New("tags: set cannot be combined with add or remove")
+	}
+	if req.Media != nil {
+		if req.Media.Set != nil {
+			if len(req.Media.Add) > 0 || len(req.Media.Remove) > 0 {
+				return errors.New("media: set cannot be combined with add or remove")
+			}
+			return validateMedia(*req.Media.Set)
+		}
+		return validateMedia(req.Media.Add)
+	}
+	return nil
+}
+
+// Частичное обновление поста: меняются только переданные поля, теги и медиа
+// заменяются (set) или дополняются и удаляются (add/remove). Через status и
+// publish_at черновик публикуется или откладывается.
+// Если передан If-Match и версия поста уже другая, возвращается 412
+func (h *PostHandler) PatchPost(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.respondWithError(c, http.StatusBadRequest, "Invalid post ID", err)
+	}
+	userID := c.Get("user_id").(int)
+
+	req := new(model.PatchPostRequest)
+	if err := c.Bind(req); err != nil {
+		return h.respondWithError(c, http.StatusBadRequest, "Invalid request", err)
+	}
+	if err := validatePatch(req); err != nil {
+		return h.respondWithError(c, http.StatusBadRequest, err.Error(), nil)
+	}
+
+	err = h.editPost(c.Request().Context(), id, userID, c.Request().Header.Get("If-Match"),
+		func(ctx context.Context, tx bun.Tx, post *model.Post) error {
+			if req.Title != nil || req.Content != nil {
+				query := tx.NewUpdate().
+					Model((*model.Post)(nil)).
+					Where("id = ?", id)
+				if req.Title != nil {
+					query = query.Set("title = ?", *req.Title)
+				}
+				if req.Content != nil {
+					query = query.Set("content = ?", *req.Content)
+				}
+				if _, err := query.Exec(ctx); err != nil {
+					return fmt.Errorf("failed to update post: %w", err)
+				}
+			}
+
+			if req.Tags != nil {
+				if err := patchTags(ctx, tx, id, req.Tags); err != nil {
+					return err
+				}
+			}
+			if req.Media != nil {
+				if err := patchMedia(ctx, tx, id, req.Media); err != nil {
+					return err
+				}
+			}
+			if req.Status != nil || req.PublishAt != nil {
+				return setPostStatus(ctx, tx, post, req.Status, req.PublishAt)
+			}
+			return nil
+		})
+	if err != nil {
+		return h.editErrorResponse(c, id, err)
+	}
+	return h.respondWithPost(c, id)
+}
+
+func patchTags(ctx context.Context, tx bun.Tx, postID int, patch *model.TagsPatch) error {
+	if patch.Set != nil {
+		_, err := tx.NewDelete().
+			Model((*model.PostTag)(nil)).
+			Where("post_id = ?", postID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete old tags: %w", err)
+		}
+		return manageTags(ctx, tx, postID, *patch.Set)
+	}
+
+	if len(patch.Remove) > 0 {
+		_, err := tx.NewDelete().
+			Model((*model.PostTag)(nil)).
+			Where("post_id = ?", postID).
+			Where("tag_id IN (?)", tx.NewSelect().
+				Model((*model.Tag)(nil)).
+				Column("id").
+				Where("name IN (?)", bun.In(patch.Remove))).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to remove tags: %w", err)
+		}
+	}
+	return manageTags(ctx, tx, postID, patch.Add)
+}
+
+// mediaLimitError — после изменения у поста оказалось бы слишком много медиа
+type mediaLimitError struct{}
+
+func (e *mediaLimitError) Error() string {
+	return fmt.Sprintf("too many media items: at most %d allowed", maxPostMedia)
+}
+
+func patchMedia(ctx context.Context, tx bun.Tx, postID int, patch *model.MediaPatch) error {
+	if patch.Set != nil {
+		_, err := tx.NewDelete().
+			Model((*model.Media)(nil)).
+			Where("post_id = ?", postID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete old media: %w", err)
+		}
+		_, err = manageMedia(ctx, tx, postID, *patch.Set)
+		return err
+	}
+
+	if len(patch.Remove) > 0 {
+		_, err := tx.NewDelete().
+			Model((*model.Media)(nil)).
+			Where("post_id = ?", postID).
+			Where("id IN (?)", bun.In(patch.Remove)).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to remove media: %w", err)
+		}
+	}
+	if len(patch.Add) == 0 {
+		return nil
+	}
+
+	count, err := tx.NewSelect().Model((*model.Media)(nil)).Where("post_id = ?", postID).Count(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count media: %w", err)
+	}
+	if count+len(patch.Add) > maxPostMedia {
+		return &mediaLimitError{}
+	}
+	_, err = manageMedia(ctx, tx, postID, patch.Add)
+	return err
+}
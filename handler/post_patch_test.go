@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"api-service/model"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/uptrace/bun"
+)
+
+func TestIfMatchAllows(t *testing.T) {
+	etag := postETag(3)
+	cases := []struct {
+		header string
+		want   bool
+	}{
+		{"", true},
+		{`"3"`, true},
+		{`W/"3"`, true},
+		{`"1", "3"`, true},
+		{"*", true},
+		{`"2"`, false},
+		{`3`, false},
+		{`"2", W/"4"`, false},
+	}
+	for _, tc := range cases {
+		if got := ifMatchAllows(tc.header, etag); got != tc.want {
+			t.Errorf("If-Match %q против %s: получено %v, ожидалось %v", tc.header, etag, got, tc.want)
+		}
+	}
+}
+
+func TestValidatePatch(t *testing.T) {
+	empty, title := " ", "новый заголовок"
+	tags := []string{"go"}
+	media := []model.Media{{URL: "https://example.com/a.png", Type: "image"}}
+
+	cases := []struct {
+		name string
+		req  model.PatchPostRequest
+		ok   bool
+	}{
+		{"пустой запрос", model.PatchPostRequest{}, true},
+		{"заголовок", model.PatchPostRequest{Title: &title}, true},
+		{"пустой заголовок", model.PatchPostRequest{Title: &empty}, false},
+		{"теги set", model.PatchPostRequest{Tags: &model.TagsPatch{Set: &tags}}, true},
+		{"теги add и remove", model.PatchPostRequest{Tags: &model.TagsPatch{Add: tags, Remove: []string{"js"}}}, true},
+		{"теги set с add", model.PatchPostRequest{Tags: &model.TagsPatch{Set: &tags, Add: tags}}, false},
+		{"медиа set", model.PatchPostRequest{Media: &model.MediaPatch{Set: &media}}, true},
+		{"медиа set с remove", model.PatchPostRequest{Media: &model.MediaPatch{Set: &media, Remove: []int{1}}}, false},
+		{"медиа add без ссылки", model.PatchPostRequest{Media: &model.MediaPatch{Add: []model.Media{{Type: "image"}}}}, false},
+	}
+	for _, tc := range cases {
+		err := validatePatch(&tc.req)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: ошибка %v, ожидался успех = %v", tc.name, err, tc.ok)
+		}
+	}
+}
+
+func postTagNames(t *testing.T, conn bun.IDB, postID int) string {
+	t.Helper()
+	names := make([]string, 0)
+	err := conn.NewSelect().
+		Model((*model.Tag)(nil)).
+		Column("tag.name").
+		Join("JOIN post_tags AS pt ON pt.tag_id = tag.id").
+		Where("pt.post_id = ?", postID).
+		Order("tag.name").
+		Scan(context.Background(), &names)
+	if err != nil {
+		t.Fatalf("загрузка тегов: %v", err)
+	}
+	return strings.Join(names, ",")
+}
+
+func postMedia(t *testing.T, conn bun.IDB, postID int) []model.Media {
+	t.Helper()
+	media := make([]model.Media, 0)
+	if err := conn.NewSelect().Model(&media).Where("post_id = ?", postID).Order("id").Scan(context.Background()); err != nil {
+		t.Fatalf("загрузка медиа: %v", err)
+	}
+	return media
+}
+
+func TestPatchTags(t *testing.T) {
+	conn := newTestDB(t)
+	post := createTestPost(t, conn, createTestUser(t, conn, "author"), model.PostStatusPublished, nil, []string{"go", "sql"}, nil)
+
+	patch := func(p *model.TagsPatch) {
+		inTestTx(t, conn, func(ctx context.Context, tx bun.Tx) error { return patchTags(ctx, tx, post.ID, p) })
+	}
+
+	patch(&model.TagsPatch{Add: []string{"bun", "go"}, Remove: []string{"sql"}})
+	if got := postTagNames(t, conn, post.ID); got != "bun,go" {
+		t.Fatalf("после add/remove: %q, ожидалось bun,go", got)
+	}
+
+	set := []string{"echo"}
+	patch(&model.TagsPatch{Set: &set})
+	if got := postTagNames(t, conn, post.ID); got != "echo" {
+		t.Fatalf("после set: %q, ожидалось echo", got)
+	}
+
+	// Удаление отсутствующего тега ничего не ломает
+	patch(&model.TagsPatch{Remove: []string{"missing"}})
+	if got := postTagNames(t, conn, post.ID); got != "echo" {
+		t.Fatalf("после remove отсутствующего: %q", got)
+	}
+
+	empty := []string{}
+	patch(&model.TagsPatch{Set: &empty})
+	if got := postTagNames(t, conn, post.ID); got != "" {
+		t.Fatalf("пустой set должен удалить все теги, осталось %q", got)
+	}
+}
+
+func TestPatchMedia(t *testing.T) {
+	conn := newTestDB(t)
+	image := func(name string) model.Media { return model.Media{URL: "https://example.com/" + name, Type: "image"} }
+	post := createTestPost(t, conn, createTestUser(t, conn, "author"), model.PostStatusPublished, nil, nil,
+		[]model.Media{image("a.png"), image("b.png")})
+
+	patch := func(p *model.MediaPatch) error {
+		return conn.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+			return patchMedia(ctx, tx, post.ID, p)
+		})
+	}
+
+	existing := postMedia(t, conn, post.ID)
+	if err := patch(&model.MediaPatch{Remove: []int{existing[0].ID}, Add: []model.Media{image("c.png")}}); err != nil {
+		t.Fatalf("add/remove: %v", err)
+	}
+	got := postMedia(t, conn, post.ID)
+	if len(got) != 2 || got[0].URL != "https://example.com/b.png" || got[1].URL != "https://example.com/c.png" {
+		t.Fatalf("после add/remove: %+v", got)
+	}
+
+	// Добавление сверх maxPostMedia отклоняется целиком
+	tooMany := make([]model.Media, maxPostMedia-1)
+	for i := range tooMany {
+		tooMany[i] = image("x.png")
+	}
+	var limitErr *mediaLimitError
+	if err := patch(&model.MediaPatch{Add: tooMany}); !errors.As(err, &limitErr) {
+		t.Fatalf("ожидалась mediaLimitError, получено %v", err)
+	}
+	if got := postMedia(t, conn, post.ID); len(got) != 2 {
+		t.Fatalf("после отклонённого add: %d медиа, ожидалось 2", len(got))
+	}
+
+	set := []model.Media{image("d.png")}
+	if err := patch(&model.MediaPatch{Set: &set}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if got := postMedia(t, conn, post.ID); len(got) != 1 || got[0].URL != "https://example.com/d.png" {
+		t.Fatalf("после set: %+v", got)
+	}
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"api-service/model"
+	"api-service/policy"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+// errQuoteNotAllowed — цитируемый пост не найден или недоступен автору цитаты
+var errQuoteNotAllowed = errors.New("quoted post not found")
+
+// checkQuotable проверяет, что userID может процитировать пост: он опубликован,
+// виден пользователю и, как при пересылке, не принадлежит чужому закрытому аккаунту
+func checkQuotable(ctx context.Context, db bun.IDB, userID, postID int) error {
+	original := new(model.Post)
+	err := db.NewSelect().
+		Model(original).
+		Relation("User").
+		Where("post.id = ?", postID).
+		Where("post.status = ?", model.PostStatusPublished).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errQuoteNotAllowed
+	}
+	if err != nil {
+		return err
+	}
+	if original.UserID == userID {
+		return nil
+	}
+
+	visible, err := policy.CanViewPosts(ctx, db, userID, original.UserID)
+	if err != nil {
+		return err
+	}
+	if !visible || (original.User != nil && original.User.IsPrivate) {
+		return errQuoteNotAllowed
+	}
+	return nil
+}
+
+// adjustQuotesCount меняет счётчик цитат оригинала на delta, если post — опубликованная цитата
+func adjustQuotesCount(ctx context.Context, db bun.IDB, post *model.Post, delta int) error {
+	if post.QuotedPostID == nil || post.Status != model.PostStatusPublished {
+		return nil
+	}
+	_, err := db.NewUpdate().
+		Model((*model.Post)(nil)).
+		WhereAllWithDeleted().
+		Set("quotes_count = GREATEST(quotes_count + ?, 0)", delta).
+		Where("id = ?", *post.QuotedPostID).
+		Exec(ctx)
+	return err
+}
+
+// postRefs возвращает указатели на элементы среза для attachQuotes
+func postRefs(posts []model.Post) []*model.Post {
+	refs := make([]*model.Post, len(posts))
+	for i := range posts {
+		refs[i] = &posts[i]
+	}
+	return refs
+}
+
+// visibilityCache запоминает результат policy.CanViewPosts по автору в пределах одного запроса
+type visibilityCache struct {
+	db       bun.IDB
+	viewerID int
+	seen     map[int]bool
+}
+
+func newVisibilityCache(db bun.IDB, viewerID int) *visibilityCache {
+	return &visibilityCache{db: db, viewerID: viewerID, seen: make(map[int]bool)}
+}
+
+func (v *visibilityCache) canView(ctx context.Context, authorID int) (bool, error) {
+	if visible, ok := v.seen[authorID]; ok {
+		return visible, nil
+	}
+	visible, err := policy.CanViewPosts(ctx, v.db, v.viewerID, authorID)
+	if err != nil {
+		return false, err
+	}
+	v.seen[authorID] = visible
+	return visible, nil
+}
+
+// attachQuotes подставляет в цитаты их оригиналы. Вместо удалённого оригинала
+// или скрытого от viewerID отдаётся заглушка
+func attachQuotes(ctx context.Context, db bun.IDB, viewerID int, posts ...*model.Post) error {
+	ids := make([]int, 0)
+	for _, post := range posts {
+		if post.QuotedPostID != nil {
+			ids = append(ids, *post.QuotedPostID)
+		}
+	}
+
+	originals := make(map[int]*model.Post, len(ids))
+	if len(ids) > 0 {
+		var found []model.Post
+		err := db.NewSelect().
+			Model(&found).
+			Relation("Media").
+			Where("post.id IN (?)", bun.In(ids)).
+			Where("post.status = ?", model.PostStatusPublished).
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+		for i := range found {
+			originals[found[i].ID] = &found[i]
+		}
+	}
+
+	visibility := newVisibilityCache(db, viewerID)
+	for _, post := range posts {
+		if !post.IsQuote {
+			continue
+		}
+		var original *model.Post
+		if post.QuotedPostID != nil {
+			original = originals[*post.QuotedPostID]
+		}
+		if original == nil {
+			post.Quote = &model.QuotedPost{Tombstone: model.TombstoneDeleted}
+			continue
+		}
+
+		canView, err := visibility.canView(ctx, original.UserID)
+		if err != nil {
+			return err
+		}
+		if !canView {
+			post.Quote = &model.QuotedPost{Tombstone: model.TombstoneUnavailable}
+			continue
+		}
+		post.Quote = &model.QuotedPost{Post: original}
+	}
+	return nil
+}
+
+// Цитаты поста, новые первыми
+func (h *PostHandler) GetPostQuotes(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
+	}
+	viewerID, _ := c.Get("user_id").(int)
+	ctx := c.Request().Context()
+
+	original := new(model.Post)
+	err = h.DB.NewSelect().Model(original).Column("id", "user_id").
+		Where("id = ?", postID).
+		Where("status = ?", model.PostStatusPublished).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve post"})
+	}
+	visible, err := policy.CanViewPosts(ctx, h.DB, viewerID, original.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check visibility"})
+	}
+	if !visible {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+	}
+
+	// Цитаты, скрытые от читателя, в список не попадают
+	quotes := make([]model.Post, 0)
+	err = h.DB.NewSelect().
+		Model(&quotes).
+		Relation("Tags").
+		Relation("Media").
+		Where("post.quoted_post_id = ?", postID).
+		Where("post.status = ?", model.PostStatusPublished).
+		Apply(policy.WherePostsVisible(viewerID, "post.user_id")).
+		Order("post.published_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve quotes"})
+	}
+	if err := attachQuotes(ctx, h.DB, viewerID, postRefs(quotes)...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve quoted post"})
+	}
+	if err := attachReactions(ctx, h.DB, postRefs(quotes)...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve reactions"})
+	}
+
+	return c.JSON(http.StatusOK, quotes)
+}
@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"api-service/model"
+	"api-service/policy"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+// Размер страницы списка отреагировавших
+const (
+	defaultReactorsPageSize = 50
+	maxReactorsPageSize     = 200
+)
+
+// validReaction сообщает, входит ли reaction в набор из конфигурации
+func (h *PostHandler) validReaction(reaction string) bool {
+	for _, r := range h.Reactions {
+		if r == reaction {
+			return true
+		}
+	}
+	return false
+}
+
+// setReaction ставит реакцию userID на пост, меняет её тип или снимает при reaction = "".
+// Возвращает прежний тип ("" — реакции не было) и sql.ErrNoRows, если поста нет
+// или userID не может его видеть. Снять свою реакцию можно и с невидимого поста.
+// Вызывается в транзакции вместе с обновлением счётчиков
+func setReaction(ctx context.Context, tx bun.Tx, postID, userID int, reaction string) (string, error) {
+	// KEY SHARE, как у вставки по внешнему ключу: смена типа реакции не трогает
+	// строку поста, но должна дождаться сверки счётчиков, которая держит FOR UPDATE
+	var authorID int
+	err := tx.NewSelect().
+		Model((*model.Post)(nil)).
+		Column("user_id").
+		Where("id = ?", postID).
+		Where("status = ?", model.PostStatusPublished).
+		For("KEY SHARE").
+		Scan(ctx, &authorID)
+	if err != nil {
+		return "", err
+	}
+	if reaction != "" {
+		visible, err := policy.CanViewPosts(ctx, tx, userID, authorID)
+		if err != nil {
+			return "", err
+		}
+		if !visible {
+			return "", sql.ErrNoRows
+		}
+	}
+
+	var old string
+	if reaction == "" {
+		// Снимаем реакцию; счётчики меняются, только если удалил именно этот запрос
+		err := tx.NewDelete().
+			Model((*model.PostReaction)(nil)).
+			Where("post_id = ? AND user_id = ?", postID, userID).
+			Returning("type").
+			Scan(ctx, &old)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return "", err
+		}
+	} else {
+		// Уникальная пара (post_id, user_id) не даёт параллельным запросам вставить
+		// реакцию дважды; существующая строка блокируется до конца транзакции
+		res, err := tx.NewInsert().
+			Model(&model.PostReaction{PostID: postID, UserID: userID, Type: reaction}).
+			On("CONFLICT (post_id, user_id) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return "", err
+		}
+		if inserted, _ := res.RowsAffected(); inserted == 0 {
+			err := tx.NewSelect().
+				Model((*model.PostReaction)(nil)).
+				Column("type").
+				Where("post_id = ? AND user_id = ?", postID, userID).
+				For("UPDATE").
+				Scan(ctx, &old)
+			if err != nil {
+				return "", err
+			}
+			if old == reaction {
+				return old, nil
+			}
+			_, err = tx.NewUpdate().
+				Model((*model.PostReaction)(nil)).
+				Set("type = ?", reaction).
+				Set("created_at = current_timestamp").
+				Where("post_id = ? AND user_id = ?", postID, userID).
+				Exec(ctx)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if err := adjustReactionCounts(ctx, tx, postID, old, reaction); err != nil {
+		return "", err
+	}
+	return old, nil
+}
+
+// adjustReactionCounts переносит одну реакцию поста из типа from в тип to.
+// Пустой тип означает, что реакции не было или она снята
+func adjustReactionCounts(ctx context.Context, tx bun.Tx, postID int, from, to string) error {
+	if from == to {
+		return nil
+	}
+
+	// likes_count считает реакции любого типа и меняется, только когда реакция
+	// появляется или пропадает. Строка поста блокируется первой
+	total := 0
+	if from == "" {
+		total = 1
+	} else if to == "" {
+		total = -1
+	}
+	if total != 0 {
+		_, err := tx.NewUpdate().
+			Model((*model.Post)(nil)).
+			WhereAllWithDeleted().
+			Set("likes_count = GREATEST(likes_count + ?, 0)", total).
+			Where("id = ?", postID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Строки счётчиков по типам обновляются в одном порядке, чтобы встречные
+	// смены реакции (like → love и love → like) не блокировали друг друга
+	deltas := map[string]int{}
+	if from != "" {
+		deltas[from] = -1
+	}
+	if to != "" {
+		deltas[to] = 1
+	}
+	types := make([]string, 0, len(deltas))
+	for t := range deltas {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	for _, t := range types {
+		count := &model.PostReactionCount{PostID: postID, Type: t, Count: deltas[t]}
+		if deltas[t] < 0 {
+			count.Count = 0
+		}
+		_, err := tx.NewInsert().
+			Model(count).
+			On("CONFLICT (post_id, type) DO UPDATE").
+			Set("count = GREATEST(post_reaction_count.count + ?, 0)", deltas[t]).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachReactions заполняет число реакций по типам из агрегированных счётчиков
+func attachReactions(ctx context.Context, db bun.IDB, posts ...*model.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	byID := make(map[int]*model.Post, len(posts))
+	ids := make([]int, 0, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+		ids = append(ids, post.ID)
+	}
+
+	var counts []model.PostReactionCount
+	err := db.NewSelect().
+		Model(&counts).
+		Where("post_id IN (?)", bun.In(ids)).
+		Where("count > 0").
+		Scan(ctx)
+	if err != nil {
+		return err
+	}
+	for _, count := range counts {
+		post := byID[count.PostID]
+		if post.Reactions == nil {
+			post.Reactions = make(map[string]int)
+		}
+		post.Reactions[count.Type] = count.Count
+	}
+	return nil
+}
+
+// Реакция на пост: повторный запрос с другим типом меняет реакцию
+func (h *PostHandler) SetReaction(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID поста"})
+	}
+	userID := c.Get("user_id").(int)
+
+	req := new(struct {
+		Type string `json:"type"`
+	})
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный запрос"})
+	}
+	if !h.validReaction(req.Type) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Неизвестная реакция", "allowed": h.Reactions})
+	}
+
+	err = h.DB.RunInTx(c.Request().Context(), nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := setReaction(ctx, tx, postID, userID, req.Type)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || isPgError(err, pgForeignKeyViolation) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при сохранении реакции"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Реакция сохранена", "type": req.Type})
+}
+
+// Снятие своей реакции с поста
+func (h *PostHandler) RemoveReaction(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID поста"})
+	}
+	userID := c.Get("user_id").(int)
+
+	err = h.DB.RunInTx(c.Request().Context(), nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := setReaction(ctx, tx, postID, userID, "")
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при удалении реакции"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Реакция удалена"})
+}
+
+// Кто отреагировал на пост, последние первыми: ?type=, ?limit=, ?before= (ID реакции).
+// Вместе со списком отдаётся число реакций по типам
+func (h *PostHandler) GetPostReactions(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID поста"})
+	}
+	reaction := c.QueryParam("type")
+	if reaction != "" && !h.validReaction(reaction) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Неизвестная реакция", "allowed": h.Reactions})
+	}
+	limit := defaultReactorsPageSize
+	if l := c.QueryParam("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > maxReactorsPageSize {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный limit"})
+		}
+		limit = n
+	}
+	before := 0
+	if b := c.QueryParam("before"); b != "" {
+		if before, err = strconv.Atoi(b); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный before"})
+		}
+	}
+	viewerID, _ := c.Get("user_id").(int)
+	ctx := c.Request().Context()
+
+	post := new(model.Post)
+	err = h.DB.NewSelect().Model(post).Column("id", "user_id").
+		Where("id = ?", postID).
+		Where("status = ?", model.PostStatusPublished).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении реакций"})
+	}
+	visible, err := policy.CanViewPosts(ctx, h.DB, viewerID, post.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении реакций"})
+	}
+	if !visible {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Пост не найден"})
+	}
+
+	var reactions []model.PostReaction
+	query := h.DB.NewSelect().
+		Model(&reactions).
+		Relation("User").
+		Where("post_reaction.post_id = ?", postID).
+		OrderExpr("post_reaction.id DESC").
+		Limit(limit)
+	if reaction != "" {
+		query = query.Where("post_reaction.type = ?", reaction)
+	}
+	if before != 0 {
+		query = query.Where("post_reaction.id < ?", before)
+	}
+	if err := query.Scan(ctx); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении реакций"})
+	}
+	if err := attachReactions(ctx, h.DB, post); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении реакций"})
+	}
+
+	reactors := make([]model.PostReactor, 0, len(reactions))
+	for _, r := range reactions {
+		if r.User == nil {
+			continue
+		}
+		reactors = append(reactors, model.PostReactor{User: r.User.Public(), Type: r.Type, ReactedAt: r.CreatedAt})
+	}
+
+	response := struct {
+		Reactions  []model.PostReactor `json:"reactions"`
+		Counts     map[string]int      `json:"counts"`
+		NextBefore int                 `json:"next_before,omitempty"` // Значение ?before= для следующей страницы
+	}{Reactions: reactors, Counts: post.Reactions}
+	if response.Counts == nil {
+		response.Counts = map[string]int{}
+	}
+	if len(reactions) == limit {
+		response.NextBefore = reactions[len(reactions)-1].ID
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"api-service/model"
+	"context"
+	"testing"
+
+	"github.com/uptrace/bun"
+)
+
+// reactionCounts возвращает счётчики поста по типам
+func reactionCounts(t *testing.T, conn bun.IDB, postID int) map[string]int {
+	t.Helper()
+	var rows []model.PostReactionCount
+	if err := conn.NewSelect().Model(&rows).Where("post_id = ?", postID).Scan(context.Background()); err != nil {
+		t.Fatalf("загрузка счётчиков: %v", err)
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Type] = row.Count
+	}
+	return counts
+}
+
+func TestAdjustReactionCounts(t *testing.T) {
+	conn := newTestDB(t)
+	post := createTestPost(t, conn, createTestUser(t, conn, "author"), model.PostStatusPublished, nil, nil, nil)
+
+	steps := []struct {
+		name     string
+		from, to string
+		likes    int
+		counts   map[string]int
+	}{
+		{"новая реакция", "", model.ReactionLike, 1, map[string]int{"like": 1}},
+		{"та же реакция", model.ReactionLike, model.ReactionLike, 1, map[string]int{"like": 1}},
+		{"смена типа", model.ReactionLike, "love", 1, map[string]int{"like": 0, "love": 1}},
+		{"вторая реакция", "", "love", 2, map[string]int{"like": 0, "love": 2}},
+		{"снятие реакции", "love", "", 1, map[string]int{"like": 0, "love": 1}},
+		{"снятие последней", "love", "", 0, map[string]int{"like": 0, "love": 0}},
+		// Счётчики не уходят в минус, даже если снятие пришло лишний раз
+		{"лишнее снятие", "love", "", 0, map[string]int{"like": 0, "love": 0}},
+	}
+	for _, step := range steps {
+		inTestTx(t, conn, func(ctx context.Context, tx bun.Tx) error {
+			return adjustReactionCounts(ctx, tx, post.ID, step.from, step.to)
+		})
+		if got := loadTestPost(t, conn, post.ID).LikesCount; got != step.likes {
+			t.Fatalf("%s: likes_count = %d, ожидалось %d", step.name, got, step.likes)
+		}
+		counts := reactionCounts(t, conn, post.ID)
+		if len(counts) != len(step.counts) {
+			t.Fatalf("%s: счётчики %v, ожидалось %v", step.name, counts, step.counts)
+		}
+		for typ, want := range step.counts {
+			if counts[typ] != want {
+				t.Fatalf("%s: счётчики %v, ожидалось %v", step.name, counts, step.counts)
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"api-service/model"
+	"api-service/policy"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+// Подписка на пользователя. Для закрытого аккаунта создаётся заявка,
+// которую тот одобряет через AcceptFollowRequest.
+func (h *UserHandler) FollowUser(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	targetID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID пользователя"})
+	}
+	if targetID == userID {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Нельзя подписаться на себя"})
+	}
+
+	ctx := c.Request().Context()
+
+	target := new(model.User)
+	err = h.DB.NewSelect().Model(target).Column("id", "is_private").
+		Where("id = ?", targetID).
+		Where("suspended_at IS NULL"). // Заблокированные администрацией аккаунты не ищутся
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Пользователь не найден"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка получения пользователя"})
+	}
+
+	blocked, err := policy.IsBlockedEitherWay(ctx, h.DB, userID, targetID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка проверки блокировки"})
+	}
+	if blocked {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Подписка невозможна"})
+	}
+
+	follow := &model.Follow{FollowerID: userID, FolloweeID: targetID, Accepted: !target.IsPrivate}
+	_, err = h.DB.NewInsert().
+		Model(follow).
+		On("CONFLICT (follower_id, followee_id) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка подписки"})
+	}
+
+	// Повторная подписка возвращает текущее состояние
+	err = h.DB.NewSelect().Model(follow).
+		Where("follower_id = ? AND followee_id = ?", userID, targetID).
+		Scan(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка подписки"})
+	}
+
+	state := "following"
+	if !follow.Accepted {
+		state = "requested"
+	}
+	return c.JSON(http.StatusCreated, map[string]string{"status": state})
+}
+
+// Отписка от пользователя (или отзыв заявки)
+func (h *UserHandler) UnfollowUser(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	targetID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID пользователя"})
+	}
+
+	_, err = h.DB.NewDelete().
+		Model((*model.Follow)(nil)).
+		Where("follower_id = ? AND followee_id = ?", userID, targetID).
+		Exec(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка отписки"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Блокировка пользователя: подписки в обе стороны удаляются
+func (h *UserHandler) BlockUser(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	targetID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID пользователя"})
+	}
+	if targetID == userID {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Нельзя заблокировать себя"})
+	}
+
+	ctx := c.Request().Context()
+
+	exists, err := h.DB.NewSelect().Model((*model.User)(nil)).Where("id = ?", targetID).Exists(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка получения пользователя"})
+	}
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Пользователь не найден"})
+	}
+
+	err = h.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		block := &model.UserBlock{BlockerID: userID, BlockedID: targetID}
+		if _, err := tx.NewInsert().
+			Model(block).
+			On("CONFLICT (blocker_id, blocked_id) DO NOTHING").
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		_, err := tx.NewDelete().
+			Model((*model.Follow)(nil)).
+			Where("(follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+				userID, targetID, targetID, userID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка блокировки пользователя"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Разблокировка пользователя
+func (h *UserHandler) UnblockUser(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	targetID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID пользователя"})
+	}
+
+	_, err = h.DB.NewDelete().
+		Model((*model.UserBlock)(nil)).
+		Where("blocker_id = ? AND blocked_id = ?", userID, targetID).
+		Exec(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка разблокировки пользователя"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Одобрение заявки на подписку
+func (h *UserHandler) AcceptFollowRequest(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	followerID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный ID пользователя"})
+	}
+
+	res, err := h.DB.NewUpdate().
+		Model((*model.Follow)(nil)).
+		Set("accepted = TRUE").
+		Where("follower_id = ? AND followee_id = ?", followerID, userID).
+		Where("accepted = FALSE").
+		Exec(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка одобрения заявки"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Заявка не найдена"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
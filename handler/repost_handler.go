@@ -2,12 +2,19 @@ package handler
 
 import (
 	"api-service/model"
+	"api-service/policy"
+	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
 )
 
+var errAlreadyReposted = errors.New("already reposted")
+
 // Репост поста
 func (h *PostHandler) RepostPost(c echo.Context) error {
 	userID := c.Get("user_id").(int) // Получаем ID текущего пользователя
@@ -18,40 +25,58 @@ func (h *PostHandler) RepostPost(c echo.Context) error {
   
 	// Проверяем, что пользователь не пытается репостить свой пост
 	originalPost := &model.Post{}
-	if err := h.DB.NewSelect().Model(originalPost).Where("id = ?", postID).Scan(c.Request().Context()); err != nil {
+	if err := h.DB.NewSelect().Model(originalPost).Where("id = ?", postID).Where("status = ?", model.PostStatusPublished).Scan(c.Request().Context()); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+	}
+	visible, err := policy.CanViewPosts(c.Request().Context(), h.DB, userID, originalPost.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check visibility"})
+	}
+	if !visible {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
 	}
 	if originalPost.UserID == userID {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "You cannot repost your own post"})
 	}
   
-	// Проверяем, что пользователь еще не репостил этот пост
-	existingRepost := &model.Repost{}
-	if err := h.DB.NewSelect().
-		Model(existingRepost).
-		Where("original_post_id = ? AND user_id = ?", postID, userID).
-		Scan(c.Request().Context()); err == nil {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "You have already reposted this post"})
-	}
-  
-	// Создаем репост
-	repost := &model.Repost{
-		OriginalPostID: postID,
-		UserID:         userID,
-	}
-	if _, err := h.DB.NewInsert().Model(repost).Exec(c.Request().Context()); err != nil {
+	// Создаем репост и увеличиваем счетчик в одной транзакции. Уникальная пара
+	// (original_post_id, user_id) отсекает повторный и параллельный репост
+	ctx := c.Request().Context()
+	err = h.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		repost := &model.Repost{
+			OriginalPostID: postID,
+			UserID:         userID,
+		}
+		res, err := tx.NewInsert().
+			Model(repost).
+			On("CONFLICT (original_post_id, user_id) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if inserted, _ := res.RowsAffected(); inserted == 0 {
+			return errAlreadyReposted
+		}
+
+		// Увеличиваем счетчик репостов
+		_, err = tx.NewUpdate().
+			Model((*model.Post)(nil)).
+			WhereAllWithDeleted().
+			Set("reposts_count = reposts_count + 1").
+			Where("id = ?", postID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errAlreadyReposted) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "You have already reposted this post"})
+		}
+		if isPgError(err, pgForeignKeyViolation) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create repost"})
 	}
   
-	// Увеличиваем счетчик репостов
-	if _, err := h.DB.NewUpdate().
-		Model((*model.Post)(nil)).
-		Set("reposts_count = reposts_count + 1").
-		Where("id = ?", postID).
-		Exec(c.Request().Context()); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update repost count"})
-	}
-  
 	return c.JSON(http.StatusCreated, map[string]string{"message": "Repost created successfully"})
 }
 
@@ -63,25 +88,37 @@ func (h *PostHandler) DeleteRepost(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid repost ID"})
 	}
   
-	// Проверяем, что репост принадлежит текущему пользователю
-	repost := &model.Repost{}
-	if err := h.DB.NewSelect().Model(repost).Where("id = ? AND user_id = ?", repostID, userID).Scan(c.Request().Context()); err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Repost not found"})
-	}
-  
-	// Удаляем репост
-	if _, err := h.DB.NewDelete().Model(repost).Where("id = ?", repostID).Exec(c.Request().Context()); err != nil {
+	// Удаляем репост текущего пользователя и уменьшаем счетчик оригинального
+	// поста в одной транзакции; RETURNING даёт ID поста без отдельного запроса
+	ctx := c.Request().Context()
+	err = h.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		repost := &model.Repost{}
+		res, err := tx.NewDelete().
+			Model(repost).
+			Where("id = ? AND user_id = ?", repostID, userID).
+			Returning("original_post_id").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if deleted, _ := res.RowsAffected(); deleted == 0 {
+			return sql.ErrNoRows
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*model.Post)(nil)).
+			WhereAllWithDeleted().
+			Set("reposts_count = GREATEST(reposts_count - 1, 0)"). // Предотвращаем отрицательные значения
+			Where("id = ?", repost.OriginalPostID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Repost not found"})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete repost"})
 	}
   
-	// Уменьшаем счетчик репостов для оригинального поста
-	if _, err := h.DB.NewUpdate().
-		Model((*model.Post)(nil)).
-		Set("reposts_count = GREATEST(reposts_count - 1, 0)"). // Предотвращаем отрицательные значения
-		Where("id = ?", repost.OriginalPostID).
-		Exec(c.Request().Context()); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update repost count"})
-	}
-  
 	return c.JSON(http.StatusOK, map[string]string{"message": "Repost deleted successfully"})
 }
\ No newline at end of file
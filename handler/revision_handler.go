@@ -0,0 +1,348 @@
+package handler
+
+import (
+	"api-service/model"
+	"api-service/policy"
+	"api-service/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+// errEditWindowClosed — с момента публикации прошло больше PostHandler.EditWindow
+var errEditWindowClosed = errors.New("edit window has expired")
+
+// editPost выполняет правку поста в транзакции: блокирует строку, проверяет автора,
+// окно редактирования и If-Match и вызывает apply. Если apply поменял заголовок,
+// текст, теги или медиа, версия увеличивается и сохраняется ревизия; смена одного
+// статуса пост правкой не считает. Для постов, созданных до появления истории,
+// сначала сохраняется их прежнее состояние, чтобы правка не потеряла исходный текст
+func (h *PostHandler) editPost(ctx context.Context, postID, editorID int, ifMatch string,
+	apply func(ctx context.Context, tx bun.Tx, post *model.Post) error) error {
+	return h.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		post := &model.Post{}
+		err := tx.NewSelect().Model(post).Where("id = ?", postID).For("UPDATE").Scan(ctx)
+		if err != nil {
+			return err
+		}
+		if post.UserID != editorID {
+			return errPostForbidden
+		}
+		// Окно редактирования отсчитывается от публикации, черновики правятся без ограничений
+		if h.EditWindow > 0 && post.PublishedAt != nil && time.Since(*post.PublishedAt) > h.EditWindow {
+			return errEditWindowClosed
+		}
+		if !ifMatchAllows(ifMatch, postETag(post.Version)) {
+			return errPostVersionMismatch
+		}
+
+		before, err := loadRevision(ctx, tx, postID)
+		if err != nil {
+			return err
+		}
+		if err := apply(ctx, tx, post); err != nil {
+			return err
+		}
+		after, err := loadRevision(ctx, tx, postID)
+		if err != nil {
+			return err
+		}
+		if revisionUnchanged(diffRevisions(before, after)) {
+			return nil
+		}
+
+		before.EditorID = post.UserID
+		before.CreatedAt = post.CreatedAt
+		if post.EditedAt != nil {
+			before.CreatedAt = *post.EditedAt
+		}
+		if err := saveRevision(ctx, tx, before); err != nil {
+			return err
+		}
+		if err := manageMentions(ctx, tx, postID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		_, err = tx.NewUpdate().
+			Model((*model.Post)(nil)).
+			Set("version = version + 1").
+			Set("edited_at = ?", now).
+			Where("id = ?", postID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to bump post version: %w", err)
+		}
+		return snapshotRevision(ctx, tx, postID, editorID, now)
+	})
+}
+
+// revisionUnchanged сообщает, что diff не нашёл отличий между ревизиями
+func revisionUnchanged(diff *model.RevisionDiff) bool {
+	return diff.Title == nil && len(diff.Content) == 0 &&
+		len(diff.TagsAdded) == 0 && len(diff.TagsRemoved) == 0 &&
+		len(diff.MediaAdded) == 0 && len(diff.MediaRemoved) == 0
+}
+
+// editErrorResponse переводит ошибку editPost в HTTP-ответ
+func (h *PostHandler) editErrorResponse(c echo.Context, postID int, err error) error {
+	var limitErr *mediaLimitError
+	var statusErr *postStatusError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return h.respondWithError(c, http.StatusNotFound, "Post not found", nil)
+	case errors.Is(err, errPostForbidden):
+		return h.respondWithError(c, http.StatusForbidden, "You cannot edit this post", nil)
+	case errors.Is(err, errEditWindowClosed):
+		return h.respondWithError(c, http.StatusForbidden, "Edit window has expired", nil)
+	case errors.Is(err, errPostVersionMismatch):
+		return h.respondWithError(c, http.StatusPreconditionFailed, "Post was modified by another request", nil)
+	case errors.As(err, &limitErr):
+		return h.respondWithError(c, http.StatusBadRequest, limitErr.Error(), nil)
+	case errors.As(err, &statusErr):
+		return h.respondWithError(c, http.StatusBadRequest, statusErr.Error(), nil)
+	default:
+		log.Printf("Ошибка обновления поста %d: %v", postID, err)
+		return h.respondWithError(c, http.StatusInternalServerError, "Failed to update post", nil)
+	}
+}
+
+// snapshotRevision сохраняет текущее состояние поста как ревизию его текущей версии.
+// Если ревизия этой версии уже есть, ничего не делает
+func snapshotRevision(ctx context.Context, tx bun.IDB, postID, editorID int, at time.Time) error {
+	revision, err := loadRevision(ctx, tx, postID)
+	if err != nil {
+		return err
+	}
+	revision.EditorID = editorID
+	revision.CreatedAt = at
+	return saveRevision(ctx, tx, revision)
+}
+
+// loadRevision собирает ревизию из текущих заголовка, текста, тегов и медиа поста.
+// EditorID и CreatedAt заполняет вызывающий
+func loadRevision(ctx context.Context, tx bun.IDB, postID int) (*model.PostRevision, error) {
+	post := &model.Post{}
+	err := tx.NewSelect().Model(post).Column("id", "title", "content", "version").Where("id = ?", postID).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load post for revision: %w", err)
+	}
+
+	tags := make([]string, 0)
+	err = tx.NewSelect().
+		Model((*model.Tag)(nil)).
+		Column("tag.name").
+		Join("JOIN post_tags AS pt ON pt.tag_id = tag.id").
+		Where("pt.post_id = ?", postID).
+		Order("tag.name").
+		Scan(ctx, &tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tags for revision: %w", err)
+	}
+
+	media := make([]model.Media, 0)
+	if err := tx.NewSelect().Model(&media).Where("post_id = ?", postID).Order("id").Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load media for revision: %w", err)
+	}
+
+	return &model.PostRevision{
+		PostID:  postID,
+		Version: post.Version,
+		Title:   post.Title,
+		Content: post.Content,
+		Tags:    tags,
+		Media:   media,
+	}, nil
+}
+
+// saveRevision сохраняет ревизию. Если ревизия этой версии уже есть, ничего не делает
+func saveRevision(ctx context.Context, tx bun.IDB, revision *model.PostRevision) error {
+	_, err := tx.NewInsert().
+		Model(revision).
+		On("CONFLICT (post_id, version) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save revision: %w", err)
+	}
+	return nil
+}
+
+// diffRevisions описывает, чем ревизия cur отличается от prev
+func diffRevisions(prev, cur *model.PostRevision) *model.RevisionDiff {
+	diff := &model.RevisionDiff{}
+	if prev.Title != cur.Title {
+		diff.Title = &model.FieldChange{From: prev.Title, To: cur.Title}
+	}
+	if prev.Content != cur.Content {
+		for _, op := range utils.DiffLines(prev.Content, cur.Content) {
+			diff.Content = append(diff.Content, model.LineDiff{Op: op.Op, Text: op.Text})
+		}
+	}
+
+	oldTags := make(map[string]bool, len(prev.Tags))
+	for _, tag := range prev.Tags {
+		oldTags[tag] = true
+	}
+	newTags := make(map[string]bool, len(cur.Tags))
+	for _, tag := range cur.Tags {
+		newTags[tag] = true
+		if !oldTags[tag] {
+			diff.TagsAdded = append(diff.TagsAdded, tag)
+		}
+	}
+	for _, tag := range prev.Tags {
+		if !newTags[tag] {
+			diff.TagsRemoved = append(diff.TagsRemoved, tag)
+		}
+	}
+
+	// Медиа сравниваются по ссылке и типу: при замене набора ID меняются
+	mediaKey := func(m model.Media) string { return m.Type + " " + m.URL }
+	oldMedia := make(map[string]bool, len(prev.Media))
+	for _, m := range prev.Media {
+		oldMedia[mediaKey(m)] = true
+	}
+	newMedia := make(map[string]bool, len(cur.Media))
+	for _, m := range cur.Media {
+		newMedia[mediaKey(m)] = true
+		if !oldMedia[mediaKey(m)] {
+			diff.MediaAdded = append(diff.MediaAdded, m)
+		}
+	}
+	for _, m := range prev.Media {
+		if !newMedia[mediaKey(m)] {
+			diff.MediaRemoved = append(diff.MediaRemoved, m)
+		}
+	}
+	return diff
+}
+
+// История правок поста, от новых ревизий к старым, с отличиями от предыдущей версии
+func (h *PostHandler) GetPostRevisions(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
+	}
+	viewerID, _ := c.Get("user_id").(int)
+	ctx := c.Request().Context()
+
+	post := &model.Post{}
+	err = h.DB.NewSelect().Model(post).Column("id", "user_id").
+		Where("id = ?", postID).
+		Where("status = ?", model.PostStatusPublished).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve post"})
+	}
+	visible, err := policy.CanViewPosts(ctx, h.DB, viewerID, post.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check visibility"})
+	}
+	if !visible {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+	}
+
+	var revisions []model.PostRevision
+	err = h.DB.NewSelect().Model(&revisions).Where("post_id = ?", postID).Order("version ASC").Scan(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve revisions"})
+	}
+
+	history := make([]model.PostRevisionWithDiff, len(revisions))
+	for i := range revisions {
+		history[i].PostRevision = revisions[i]
+		if i > 0 {
+			history[i].Diff = diffRevisions(&revisions[i-1], &revisions[i])
+		}
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Version > history[j].Version })
+
+	return c.JSON(http.StatusOK, history)
+}
+
+// Восстановление поста из ревизии: создаёт новую версию с содержимым старой
+func (h *PostHandler) RestorePostRevision(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.respondWithError(c, http.StatusBadRequest, "Invalid post ID", err)
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return h.respondWithError(c, http.StatusBadRequest, "Invalid revision version", err)
+	}
+	userID := c.Get("user_id").(int)
+
+	err = h.editPost(c.Request().Context(), postID, userID, c.Request().Header.Get("If-Match"),
+		func(ctx context.Context, tx bun.Tx, post *model.Post) error {
+			revision := &model.PostRevision{}
+			err := tx.NewSelect().Model(revision).
+				Where("post_id = ? AND version = ?", postID, version).
+				Scan(ctx)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return errRevisionNotFound
+				}
+				return err
+			}
+
+			_, err = tx.NewUpdate().
+				Model((*model.Post)(nil)).
+				Set("title = ?", revision.Title).
+				Set("content = ?", revision.Content).
+				Where("id = ?", postID).
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to restore post: %w", err)
+			}
+			if err := patchTags(ctx, tx, postID, &model.TagsPatch{Set: &revision.Tags}); err != nil {
+				return err
+			}
+			return patchMedia(ctx, tx, postID, &model.MediaPatch{Set: &revision.Media})
+		})
+	if errors.Is(err, errRevisionNotFound) {
+		return h.respondWithError(c, http.StatusNotFound, "Revision not found", nil)
+	}
+	if err != nil {
+		return h.editErrorResponse(c, postID, err)
+	}
+	return h.respondWithPost(c, postID)
+}
+
+// errRevisionNotFound — у поста нет ревизии с запрошенной версией
+var errRevisionNotFound = errors.New("revision not found")
+
+// respondWithPost отдаёт пост с тегами и медиа и его ETag
+func (h *PostHandler) respondWithPost(c echo.Context, postID int) error {
+	post := new(model.Post)
+	err := h.DB.NewSelect().
+		Model(post).
+		Relation("Tags").
+		Relation("Media").
+		Where("post.id = ?", postID).
+		Scan(c.Request().Context())
+	if err != nil {
+		return h.respondWithError(c, http.StatusInternalServerError, "Failed to load post", nil)
+	}
+	viewerID, _ := c.Get("user_id").(int)
+	if err := attachQuotes(c.Request().Context(), h.DB, viewerID, post); err != nil {
+		return h.respondWithError(c, http.StatusInternalServerError, "Failed to load quoted post", nil)
+	}
+	if err := attachReactions(c.Request().Context(), h.DB, post); err != nil {
+		return h.respondWithError(c, http.StatusInternalServerError, "Failed to load reactions", nil)
+	}
+
+	c.Response().Header().Set("ETag", postETag(post.Version))
+	return c.JSON(http.StatusOK, post)
+}
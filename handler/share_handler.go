@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"api-service/model"
+	"api-service/policy"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+	"google.golang.org/grpc/status"
+
+	chatpb "api-service/proto/chat-service/proto"
+)
+
+// Максимум чатов в одной пересылке
+const maxShareChats = 20
+
+// Результат пересылки в один чат
+type shareResult struct {
+	ChatID    string `json:"chat_id"`
+	MessageID string `json:"message_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Вложение-ссылка на пост для сообщения в чате
+func postAttachment(post *model.Post) *chatpb.Attachment {
+	ref := &chatpb.PostReference{
+		PostId:   int32(post.ID),
+		AuthorId: int32(post.UserID),
+		Title:    post.Title,
+	}
+	if post.User != nil {
+		ref.AuthorName = post.User.Name
+		ref.AuthorAvatar = post.User.Avatar
+	}
+	if len(post.Media) > 0 {
+		ref.MediaUrl = post.Media[0].URL
+		ref.MediaType = post.Media[0].Type
+	}
+	return &chatpb.Attachment{Kind: &chatpb.Attachment_Post{Post: ref}}
+}
+
+// Пересылка поста в один или несколько чатов
+func (h *PostHandler) SharePost(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
+	}
+
+	req := new(model.SharePostRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+	if len(req.ChatIDs) == 0 || len(req.ChatIDs) > maxShareChats {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "chat_ids must contain from 1 to 20 chats"})
+	}
+
+	ctx := c.Request().Context()
+
+	post := new(model.Post)
+	err = h.DB.NewSelect().
+		Model(post).
+		Relation("User").
+		Relation("Media", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("media.id ASC")
+		}).
+		Where("post.id = ?", postID).
+		Where("post.status = ?", model.PostStatusPublished).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve post"})
+	}
+
+	visible, err := policy.CanViewPosts(ctx, h.DB, userID, post.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check post visibility"})
+	}
+	if !visible {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+	}
+	// Посты закрытого аккаунта может пересылать только автор
+	if post.User != nil && post.User.IsPrivate && post.UserID != userID {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Posts from private accounts cannot be shared"})
+	}
+
+	attachment := postAttachment(post)
+	results := make([]shareResult, 0, len(req.ChatIDs))
+	seen := make(map[string]bool, len(req.ChatIDs))
+	for _, chatID := range req.ChatIDs {
+		if seen[chatID] {
+			continue
+		}
+		seen[chatID] = true
+		results = append(results, h.shareToChat(ctx, post, userID, chatID, req.Comment, attachment))
+	}
+
+	if err := h.DB.NewSelect().Model(post).Column("shares_count").WherePK().Scan(ctx); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve shares count"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"results":      results,
+		"shares_count": post.SharesCount,
+	})
+}
+
+// shareToChat отправляет пост в один чат и учитывает пересылку
+func (h *PostHandler) shareToChat(ctx context.Context, post *model.Post, userID int, chatID, comment string, attachment *chatpb.Attachment) shareResult {
+	result := shareResult{ChatID: chatID}
+
+	chatResp, err := h.ChatServiceClient.GetChat(ctx, &chatpb.GetChatRequest{ChatId: chatID})
+	if err != nil {
+		result.Error = status.Convert(err).Message()
+		return result
+	}
+	chat := chatResp.GetChat()
+	if !isParticipant(chat, strconv.Itoa(userID)) {
+		result.Error = "chat not found"
+		return result
+	}
+
+	// Те же проверки, что и у SendMessage: в личном чате блокировка между
+	// собеседниками запрещает переписку
+	if !chat.GetIsGroup() {
+		if peerID, ok := directPeer(chat, userID); ok {
+			decision, err := canInteract(ctx, h.AuthServiceClient, userID, peerID, policy.ActionSendMessage)
+			if err != nil {
+				result.Error = "failed to check interaction policy"
+				return result
+			}
+			if !decision.GetAllowed() {
+				result.Error = "interaction with this user is not allowed"
+				return result
+			}
+		}
+	}
+
+	// Пост должен быть виден каждому участнику: не заблокирован с автором и,
+	// если аккаунт закрытый, подписан на автора
+	for _, p := range chat.GetParticipants() {
+		id, err := strconv.Atoi(p)
+		if err != nil || id == userID {
+			continue
+		}
+		visible, err := policy.CanViewPosts(ctx, h.DB, id, post.UserID)
+		if err != nil {
+			result.Error = "failed to check post visibility"
+			return result
+		}
+		if !visible {
+			result.Error = "post cannot be shared to this chat"
+			return result
+		}
+	}
+
+	msgResp, err := h.ChatServiceClient.SendMessage(ctx, &chatpb.SendMessageRequest{
+		ChatId:      chatID,
+		SenderId:    strconv.Itoa(userID),
+		Content:     comment,
+		Attachments: []*chatpb.Attachment{attachment},
+	})
+	if err != nil {
+		result.Error = status.Convert(err).Message()
+		return result
+	}
+	result.MessageID = msgResp.GetMessage().GetId()
+
+	// Учитываем пересылку
+	err = h.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		share := &model.PostShare{PostID: post.ID, UserID: userID, ChatID: chatID, MessageID: result.MessageID}
+		if _, err := tx.NewInsert().Model(share).Exec(ctx); err != nil {
+			return err
+		}
+		_, err := tx.NewUpdate().
+			Model((*model.Post)(nil)).
+			WhereAllWithDeleted().
+			Set("shares_count = shares_count + 1").
+			Where("id = ?", post.ID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		// Сообщение уже отправлено, поэтому пересылка считается успешной
+		log.Printf("Ошибка учёта пересылки поста %d в чат %s: %v", post.ID, chatID, err)
+	}
+
+	return result
+}
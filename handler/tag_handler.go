@@ -2,15 +2,27 @@ package handler
 
 import (
 	"api-service/model"
+	"api-service/policy"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 )
 
-// Получение всех тегов
+// Получение всех тегов. Теги, которые есть только у черновиков, отложенных,
+// удалённых и скрытых от читателя постов, не показываются
 func (h *PostHandler) GetAllTags(c echo.Context) error {
+	viewerID, _ := c.Get("user_id").(int)
 	tags := make([]model.Tag, 0)
-	err := h.DB.NewSelect().Model(&tags).Scan(c.Request().Context())
+	err := h.DB.NewSelect().
+		Model(&tags).
+		Where("EXISTS (?)", h.DB.NewSelect().
+			Model((*model.Post)(nil)).
+			ColumnExpr("1").
+			Join("JOIN post_tags AS pt ON pt.post_id = post.id").
+			Where("pt.tag_id = tag.id").
+			Where("post.status = ?", model.PostStatusPublished).
+			Apply(policy.WherePostsVisible(viewerID, "post.user_id"))).
+		Scan(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка при получении тегов"})
 	}
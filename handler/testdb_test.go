@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"api-service/db"
+	"api-service/db/dbtest"
+	"api-service/model"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// newTestDB — схема PostgreSQL с применёнными миграциями; без TEST_DATABASE_URL тест пропускается
+func newTestDB(t *testing.T) *bun.DB {
+	t.Helper()
+	conn := dbtest.Open(t)
+	m, err := db.NewMigrator(conn)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("миграции: %v", err)
+	}
+	return conn
+}
+
+func createTestUser(t *testing.T, conn bun.IDB, name string) int {
+	t.Helper()
+	user := &model.User{Name: name, Email: fmt.Sprintf("%s-%d@example.com", name, time.Now().UnixNano()), Password: "x"}
+	if _, err := conn.NewInsert().Model(user).Exec(context.Background()); err != nil {
+		t.Fatalf("создание пользователя %s: %v", name, err)
+	}
+	return int(user.ID)
+}
+
+// createTestPost создаёт пост тем же путём, что и CreatePost
+func createTestPost(t *testing.T, conn *bun.DB, userID int, status string, publishAt *time.Time, tags []string, media []model.Media) *model.Post {
+	t.Helper()
+	post := newPost(userID, "title", "content", status, publishAt)
+	err := conn.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		return insertPost(ctx, tx, post, tags, media)
+	})
+	if err != nil {
+		t.Fatalf("создание поста: %v", err)
+	}
+	return post
+}
+
+func loadTestPost(t *testing.T, conn bun.IDB, postID int) *model.Post {
+	t.Helper()
+	post := new(model.Post)
+	err := conn.NewSelect().Model(post).WhereAllWithDeleted().Where("id = ?", postID).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("загрузка поста %d: %v", postID, err)
+	}
+	return post
+}
+
+// inTestTx выполняет fn в транзакции и проваливает тест при ошибке
+func inTestTx(t *testing.T, conn *bun.DB, fn func(ctx context.Context, tx bun.Tx) error) {
+	t.Helper()
+	if err := conn.RunInTx(context.Background(), nil, fn); err != nil {
+		t.Fatalf("транзакция: %v", err)
+	}
+}
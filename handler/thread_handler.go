@@ -0,0 +1,381 @@
+package handler
+
+import (
+	"api-service/model"
+	"api-service/policy"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+// Ограничения веток и цепочек
+const (
+	maxThreadPosts       = 25  // Сколько постов можно опубликовать одной цепочкой
+	defaultThreadDepth   = 3   // Глубина дерева ответов по умолчанию
+	maxThreadDepth       = 10  // Максимальная глубина дерева ответов
+	defaultThreadReplies = 20  // Прямых ответов на странице по умолчанию
+	maxThreadReplies     = 100 // Максимум прямых ответов на странице
+	threadNestedReplies  = 5   // Сколько ответов показывается у вложенных постов
+	maxThreadNodes       = 500 // Сколько всего ответов отдаётся за один запрос
+	maxThreadAncestors   = 100 // Сколько предков поднимается от поста к корню
+)
+
+// errReplyNotAllowed — пост, на который отвечают, не найден или недоступен
+var errReplyNotAllowed = errors.New("reply target not found")
+
+// checkReplyTarget проверяет, что userID может ответить на пост, и возвращает его.
+// Настройка комментариев поста ограничивает и ответы постами
+func checkReplyTarget(ctx context.Context, db bun.IDB, userID, postID int) (*model.Post, error) {
+	parent := new(model.Post)
+	err := db.NewSelect().
+		Model(parent).
+		Column("id", "user_id", "conversation_id", "title", "content", "comments_policy").
+		Where("id = ?", postID).
+		Where("status = ?", model.PostStatusPublished).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errReplyNotAllowed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	visible, err := policy.CanViewPosts(ctx, db, userID, parent.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, errReplyNotAllowed
+	}
+
+	allowed, err := policy.CanComment(ctx, db, userID, parent)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errCommentsRestricted
+	}
+	return parent, nil
+}
+
+// setReplyTarget делает post ответом на parent в той же ветке
+func setReplyTarget(post, parent *model.Post) {
+	post.InReplyToID = &parent.ID
+	post.ConversationID = parent.ConversationID
+	if post.ConversationID == 0 {
+		post.ConversationID = parent.ID
+	}
+}
+
+// Публикация цепочки постов одним запросом: каждый следующий пост отвечает
+// на предыдущий, все посты сохраняются в одной транзакции
+func (h *PostHandler) CreateThread(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	request := new(model.CreateThreadRequest)
+	if err := c.Bind(request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if len(request.Posts) == 0 || len(request.Posts) > maxThreadPosts {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("posts must contain from 1 to %d items", maxThreadPosts),
+		})
+	}
+	for i, item := range request.Posts {
+		if err := validateMedia(item.Media); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("posts[%d]: %v", i, err)})
+		}
+	}
+	status, err := resolvePostStatus(request.Status, request.PublishAt)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	commentsPolicy, err := resolveCommentsPolicy(request.CommentsPolicy)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var parent *model.Post
+	if request.InReplyToID != nil {
+		parent, err = checkReplyTarget(c.Request().Context(), h.DB, userID, *request.InReplyToID)
+		if errors.Is(err, errReplyNotAllowed) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "reply target not found"})
+		}
+		if errors.Is(err, errCommentsRestricted) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "replies are restricted by the post author"})
+		}
+		if err != nil {
+			log.Printf("Ошибка проверки поста, на который отвечают: %v", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create thread"})
+		}
+	}
+
+	posts := make([]*model.Post, 0, len(request.Posts))
+	err = h.DB.RunInTx(c.Request().Context(), nil, func(ctx context.Context, tx bun.Tx) error {
+		for _, item := range request.Posts {
+			post := newPost(userID, item.Title, item.Content, status, request.PublishAt)
+			post.CommentsPolicy = commentsPolicy
+			if parent != nil {
+				setReplyTarget(post, parent)
+			}
+			if err := insertPost(ctx, tx, post, item.Tags, item.Media); err != nil {
+				return err
+			}
+			posts = append(posts, post)
+			parent = post
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Ошибка создания цепочки постов: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create thread"})
+	}
+
+	return c.JSON(http.StatusCreated, posts)
+}
+
+// Цепочка предков поста от корня к родителю. Удалённые в корзину посты тоже
+// входят в цепочку, чтобы она не обрывалась; вместо них отдаются заглушки
+const threadAncestorsQuery = `
+WITH RECURSIVE chain AS (
+	SELECT id, in_reply_to_id, 0 AS depth FROM posts WHERE id = ?
+	UNION ALL
+	SELECT p.id, p.in_reply_to_id, chain.depth + 1
+	FROM posts AS p
+	JOIN chain ON p.id = chain.in_reply_to_id
+	WHERE chain.depth < ?
+)
+SELECT id FROM chain WHERE depth > 0 ORDER BY depth DESC`
+
+// Первые ответы на каждый из постов, не больше ? на пост
+const threadChildrenQuery = `
+SELECT id, in_reply_to_id FROM (
+	SELECT id, in_reply_to_id,
+		ROW_NUMBER() OVER (PARTITION BY in_reply_to_id ORDER BY published_at, id) AS rn
+	FROM posts
+	WHERE in_reply_to_id IN (?) AND status = ?
+) AS ranked
+WHERE rn <= ?
+ORDER BY in_reply_to_id, rn`
+
+type threadReply struct {
+	ID       int `bun:"id"`
+	ParentID int `bun:"in_reply_to_id"`
+}
+
+// threadQueryInt читает положительный параметр запроса с ограничением сверху
+func threadQueryInt(c echo.Context, name string, def, max int) (int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 || n > max {
+		return 0, fmt.Errorf("%s must be from 1 to %d", name, max)
+	}
+	return n, nil
+}
+
+// Ветка вокруг поста: предки до корня и дерево ответов до глубины ?depth=.
+// Прямые ответы отдаются постранично (?limit=, ?after=), у вложенных
+// показываются первые threadNestedReplies, а об остальных говорит more_replies
+func (h *PostHandler) GetPostThread(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
+	}
+	depth, err := threadQueryInt(c, "depth", defaultThreadDepth, maxThreadDepth)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	limit, err := threadQueryInt(c, "limit", defaultThreadReplies, maxThreadReplies)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	after := 0
+	if value := c.QueryParam("after"); value != "" {
+		if after, err = strconv.Atoi(value); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid after"})
+		}
+	}
+	viewerID, _ := c.Get("user_id").(int)
+	ctx := c.Request().Context()
+	visibility := newVisibilityCache(h.DB, viewerID)
+
+	focus := new(model.Post)
+	err = h.DB.NewSelect().
+		Model(focus).
+		Relation("Tags").
+		Relation("Media").
+		Where("post.id = ?", postID).
+		Where("post.status = ?", model.PostStatusPublished).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve post"})
+	}
+	visible, err := visibility.canView(ctx, focus.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check visibility"})
+	}
+	if !visible {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+	}
+
+	var ancestorIDs []int
+	if focus.InReplyToID != nil {
+		if err := h.DB.NewRaw(threadAncestorsQuery, postID, maxThreadAncestors).Scan(ctx, &ancestorIDs); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve thread"})
+		}
+	}
+
+	// Прямые ответы — постранично, включая удалённые: на них могут быть ответы
+	var replyIDs []int
+	query := h.DB.NewSelect().
+		Model((*model.Post)(nil)).
+		WhereAllWithDeleted().
+		Column("id").
+		Where("in_reply_to_id = ?", postID).
+		Where("status = ?", model.PostStatusPublished).
+		OrderExpr("published_at ASC, id ASC").
+		Limit(limit + 1)
+	if after != 0 {
+		query = query.Where("(published_at, id) > (SELECT published_at, id FROM posts WHERE id = ?)", after)
+	}
+	if err := query.Scan(ctx, &replyIDs); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve replies"})
+	}
+	thread := &model.PostThread{Ancestors: []model.ThreadNode{}, Post: focus, Replies: []model.ThreadNode{}}
+	if len(replyIDs) > limit {
+		replyIDs = replyIDs[:limit]
+		thread.NextAfter = &replyIDs[limit-1]
+	}
+
+	// Вложенные ответы — по уровням. На последнем уровне (или когда ответов
+	// набралось maxThreadNodes) только проверяется, есть ли ответы дальше
+	children := make(map[int][]int)
+	leaves := make(map[int]bool)
+	loadIDs := append(append([]int{}, ancestorIDs...), replyIDs...)
+	level := replyIDs
+	for d := 1; len(level) > 0; d++ {
+		last := d >= depth || len(loadIDs)+len(level)*threadNestedReplies > maxThreadNodes
+		perParent := threadNestedReplies + 1
+		if last {
+			perParent = 1
+		}
+
+		var rows []threadReply
+		err := h.DB.NewRaw(threadChildrenQuery, bun.In(level), model.PostStatusPublished, perParent).Scan(ctx, &rows)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve replies"})
+		}
+		var next []int
+		for _, r := range rows {
+			children[r.ParentID] = append(children[r.ParentID], r.ID)
+			if !last && len(children[r.ParentID]) <= threadNestedReplies {
+				next = append(next, r.ID)
+			}
+		}
+		if last {
+			for _, id := range level {
+				leaves[id] = true
+			}
+			break
+		}
+		loadIDs = append(loadIDs, next...)
+		level = next
+	}
+
+	loaded := make(map[int]*model.Post)
+	if len(loadIDs) > 0 {
+		var posts []model.Post
+		err := h.DB.NewSelect().
+			Model(&posts).
+			Relation("Tags").
+			Relation("Media").
+			Where("post.id IN (?)", bun.In(loadIDs)).
+			Where("post.status = ?", model.PostStatusPublished).
+			Scan(ctx)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve thread"})
+		}
+		for i := range posts {
+			loaded[posts[i].ID] = &posts[i]
+		}
+	}
+	refs := []*model.Post{focus}
+	for _, post := range loaded {
+		refs = append(refs, post)
+	}
+	if err := attachQuotes(ctx, h.DB, viewerID, refs...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve quoted posts"})
+	}
+	if err := attachReactions(ctx, h.DB, refs...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve reactions"})
+	}
+
+	node := func(id int) (model.ThreadNode, error) {
+		post, ok := loaded[id]
+		if !ok {
+			return model.ThreadNode{Tombstone: model.TombstoneDeleted}, nil
+		}
+		visible, err := visibility.canView(ctx, post.UserID)
+		if err != nil {
+			return model.ThreadNode{}, err
+		}
+		if !visible {
+			return model.ThreadNode{Tombstone: model.TombstoneUnavailable}, nil
+		}
+		return model.ThreadNode{Post: post}, nil
+	}
+	var build func(id int) (model.ThreadNode, error)
+	build = func(id int) (model.ThreadNode, error) {
+		n, err := node(id)
+		if err != nil {
+			return n, err
+		}
+		replies := children[id]
+		if leaves[id] {
+			n.MoreReplies = len(replies) > 0
+			return n, nil
+		}
+		if len(replies) > threadNestedReplies {
+			replies = replies[:threadNestedReplies]
+			n.MoreReplies = true
+		}
+		for _, replyID := range replies {
+			reply, err := build(replyID)
+			if err != nil {
+				return n, err
+			}
+			n.Replies = append(n.Replies, reply)
+		}
+		return n, nil
+	}
+
+	for _, id := range ancestorIDs {
+		n, err := node(id)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check visibility"})
+		}
+		thread.Ancestors = append(thread.Ancestors, n)
+	}
+	for _, id := range replyIDs {
+		n, err := build(id)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check visibility"})
+		}
+		thread.Replies = append(thread.Replies, n)
+	}
+
+	return c.JSON(http.StatusOK, thread)
+}
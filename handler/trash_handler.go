@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"api-service/model"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+// trashCutoff — раньше этого момента удалённое уже не восстанавливается
+func (h *PostHandler) trashCutoff() time.Time {
+	return time.Now().Add(-h.TrashRetention)
+}
+
+// Корзина текущего пользователя: удалённые посты и комментарии, которые ещё можно восстановить.
+// Комментарий попадает в корзину того, кто его удалил: автора комментария или автора поста
+func (h *PostHandler) GetTrash(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	ctx := c.Request().Context()
+	cutoff := h.trashCutoff()
+
+	var posts []model.Post
+	err := h.DB.NewSelect().
+		Model(&posts).
+		WhereDeleted().
+		Where("post.user_id = ?", userID).
+		Where("post.deleted_at > ?", cutoff).
+		Order("post.deleted_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve deleted posts"})
+	}
+
+	var comments []model.Comment
+	err = h.DB.NewSelect().
+		Model(&comments).
+		WhereDeleted().
+		Where("comment.deleted_by = ?", userID).
+		Where("comment.deleted_at > ?", cutoff).
+		Order("comment.deleted_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve deleted comments"})
+	}
+
+	type trashedPost struct {
+		model.Post
+		DeletedAt time.Time `json:"deleted_at"`
+		PurgeAt   time.Time `json:"purge_at"`
+	}
+	type trashedComment struct {
+		model.Comment
+		DeletedAt time.Time `json:"deleted_at"`
+		PurgeAt   time.Time `json:"purge_at"`
+	}
+	response := struct {
+		Posts    []trashedPost    `json:"posts"`
+		Comments []trashedComment `json:"comments"`
+	}{
+		Posts:    make([]trashedPost, 0, len(posts)),
+		Comments: make([]trashedComment, 0, len(comments)),
+	}
+	for _, p := range posts {
+		response.Posts = append(response.Posts, trashedPost{Post: p, DeletedAt: p.DeletedAt, PurgeAt: p.DeletedAt.Add(h.TrashRetention)})
+	}
+	for _, cm := range comments {
+		response.Comments = append(response.Comments, trashedComment{Comment: cm, DeletedAt: cm.DeletedAt, PurgeAt: cm.DeletedAt.Add(h.TrashRetention)})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// Восстановление поста из корзины. Счётчики поста не менялись при удалении,
+// поэтому он возвращается с прежними лайками, комментариями и репостами.
+// Восстановленная цитата снова учитывается в счётчике оригинала
+func (h *PostHandler) RestorePost(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
+	}
+	userID := c.Get("user_id").(int)
+
+	err = h.DB.RunInTx(c.Request().Context(), nil, func(ctx context.Context, tx bun.Tx) error {
+		post := new(model.Post)
+		res, err := tx.NewUpdate().
+			Model(post).
+			WhereDeleted().
+			Set("deleted_at = NULL").
+			Where("id = ?", postID).
+			Where("user_id = ?", userID).
+			Where("deleted_at > ?", h.trashCutoff()).
+			Returning("quoted_post_id, status").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if restored, _ := res.RowsAffected(); restored == 0 {
+			return sql.ErrNoRows
+		}
+		return adjustQuotesCount(ctx, tx, post, 1)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found in trash"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to restore post"})
+	}
+
+	return h.respondWithPost(c, postID)
+}
+
+// Восстановление комментария из корзины вместе со счётчиком комментариев поста.
+// Восстановить может только тот, кто удалил
+func (h *PostHandler) RestoreComment(c echo.Context) error {
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid comment ID"})
+	}
+	userID := c.Get("user_id").(int)
+
+	comment := &model.Comment{}
+	err = h.DB.RunInTx(c.Request().Context(), nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewUpdate().
+			Model(comment).
+			WhereDeleted().
+			Set("deleted_at = NULL").
+			Set("deleted_by = NULL").
+			Where("id = ?", commentID).
+			Where("deleted_by = ?", userID).
+			Where("deleted_at > ?", h.trashCutoff()).
+			Returning("*").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if restored, _ := res.RowsAffected(); restored == 0 {
+			return sql.ErrNoRows
+		}
+		if err := adjustRepliesCount(ctx, tx, comment.ParentID, 1); err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*model.Post)(nil)).
+			WhereAllWithDeleted().
+			Set("comments_count = comments_count + 1").
+			Where("id = ?", comment.PostID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Comment not found in trash"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to restore comment"})
+	}
+
+	return c.JSON(http.StatusOK, comment)
+}
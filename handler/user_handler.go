@@ -2,12 +2,13 @@ package handler
 
 import (
 	"api-service/model"
+	"api-service/storage"
 	"api-service/utils"
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/uptrace/bun"
@@ -20,6 +21,19 @@ type UserHandler struct {
 	DB               *bun.DB
 	AuthServiceClient proto.AuthServiceClient
 	ChatServiceClient chatpb.ChatServiceClient // Добавьте это поле
+	UserCache         *utils.TTLCache[int32, model.PublicUser] // Кэш публичных профилей для AuthService
+	Storage           storage.Blob                             // Хранилище вложений чатов
+	URLSigner         *storage.Signer                          // Подпись ссылок на вложения
+	JWTSecret         []byte                                   // Ключ подписи токенов
+	TokenTTL          time.Duration                            // Время жизни токена
+	WSAllowedOrigins  []string                                 // Сайты, кроме своего, которым можно открыть WebSocket чата
+}
+
+// Сбрасывает кэшированный публичный профиль после изменения пользователя
+func (h *UserHandler) invalidateUser(userID int) {
+	if h.UserCache != nil {
+		h.UserCache.Delete(int32(userID))
+	}
 }
 
 // Проверка наличия email в базе
@@ -84,12 +98,37 @@ func (h *UserHandler) UpdateUser(c echo.Context) error {
     if req.Status != "" {
         query.Set("status = ?", req.Status)
     }
+    if req.DMPolicy != "" {
+        switch req.DMPolicy {
+        case model.DMPolicyEveryone, model.DMPolicyFollowers, model.DMPolicyNobody:
+        default:
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректное значение dm_policy"})
+        }
+        query.Set("dm_policy = ?", req.DMPolicy)
+    }
+    if req.IsPrivate != nil {
+        query.Set("is_private = ?", *req.IsPrivate)
+    }
 
     // Выполняем обновление
     _, err := query.Exec(context.Background())
     if err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка обновления пользователя"})
     }
+    // Открытый аккаунт больше не требует подтверждения: одобряем ожидающие заявки
+    if req.IsPrivate != nil && !*req.IsPrivate {
+        _, err = h.DB.NewUpdate().
+            Model((*model.Follow)(nil)).
+            Set("accepted = TRUE").
+            Where("followee_id = ?", userID).
+            Where("accepted = FALSE").
+            Exec(context.Background())
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка одобрения заявок на подписку"})
+        }
+    }
+
+    h.invalidateUser(userID)
 
     return c.JSON(http.StatusOK, map[string]string{"message": "Пользователь обновлен"})
 }
@@ -119,126 +158,99 @@ func (h *UserHandler) DeleteUser(c echo.Context) error {
 		}
 	}()
 
-	// Шаг 1. Агрегируем данные для обновления счетчиков (до удаления записей)
-	type countResult struct {
-		PostID int `bun:"post_id"`
-		Count  int `bun:"cnt"`
-	}
-
-	var likeUpdates []countResult
-	err = tx.NewSelect().ColumnExpr("post_id, COUNT(*) AS cnt").
-		Model((*model.PostLike)(nil)).
-		Where("user_id = ?", userID).
-		Group("post_id").
-		Scan(ctx, &likeUpdates)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка выборки лайков"})
-	}
-
-	var repostUpdates []countResult
-	err = tx.NewSelect().ColumnExpr("original_post_id AS post_id, COUNT(*) AS cnt").
-		Model((*model.Repost)(nil)).
-		Where("user_id = ?", userID).
-		Group("original_post_id").
-		Scan(ctx, &repostUpdates)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка выборки репостов"})
+	// Шаг 1. Блокируем строку пользователя: вставки, ссылающиеся на неё по внешнему
+	// ключу, ждут конца транзакции, поэтому новые лайки и комментарии не проскочат
+	// между пересчётом счётчиков и удалением
+	err = tx.NewSelect().Model((*model.User)(nil)).Column("id").
+		Where("id = ?", userID).
+		For("UPDATE").
+		Scan(ctx, new(int))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Пользователь не найден"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка блокировки пользователя"})
 	}
 
-	var commentUpdates []countResult
-	err = tx.NewSelect().ColumnExpr("post_id, COUNT(*) AS cnt").
-		Model((*model.Comment)(nil)).
-		Where("user_id = ?", userID).
-		Group("post_id").
-		Scan(ctx, &commentUpdates)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка выборки комментариев"})
+	// Шаг 2. Уменьшаем счетчики чужих постов на число реакций, репостов, комментариев,
+	// пересылок и опубликованных цитат пользователя. Его собственные посты удалятся целиком
+	counters := []struct {
+		model  interface{}
+		column string
+		postFK string
+		where  string // Какие строки учитываются в счётчике, если не все
+		errMsg string
+	}{
+		{(*model.PostReaction)(nil), "likes_count", "post_id", "", "Ошибка обновления счетчика реакций"},
+		{(*model.Repost)(nil), "reposts_count", "original_post_id", "", "Ошибка обновления счетчика репостов"},
+		{(*model.Comment)(nil), "comments_count", "post_id", "", "Ошибка обновления счетчика комментариев"},
+		{(*model.PostShare)(nil), "shares_count", "post_id", "", "Ошибка обновления счетчика пересылок"},
+		{(*model.Post)(nil), "quotes_count", "quoted_post_id", "status = '" + model.PostStatusPublished + "'", "Ошибка обновления счетчика цитат"},
 	}
-
-	// Шаг 2. Обновляем счетчики в постах с учетом найденных данных
-	for _, upd := range likeUpdates {
-		_, err = tx.NewUpdate().Model((*model.Post)(nil)).
-			Set("likes_count = GREATEST(likes_count - ?, 0)", upd.Count).
-			Where("id = ?", upd.PostID).
-			Exec(ctx)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка обновления счетчика лайков"})
+	for _, counter := range counters {
+		counts := tx.NewSelect().Model(counter.model).
+			ColumnExpr("? AS post_id, COUNT(*) AS cnt", bun.Ident(counter.postFK)).
+			Where("user_id = ?", userID).
+			GroupExpr("?", bun.Ident(counter.postFK))
+		if counter.where != "" {
+			counts = counts.Where(counter.where)
 		}
-	}
-
-	for _, upd := range repostUpdates {
 		_, err = tx.NewUpdate().Model((*model.Post)(nil)).
-			Set("reposts_count = GREATEST(reposts_count - ?, 0)", upd.Count).
-			Where("id = ?", upd.PostID).
+			WhereAllWithDeleted().
+			TableExpr("(?) AS counts", counts).
+			Set("? = GREATEST(post.? - counts.cnt, 0)", bun.Ident(counter.column), bun.Ident(counter.column)).
+			Where("post.id = counts.post_id").
+			Where("post.user_id <> ?", userID).
 			Exec(ctx)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка обновления счетчика репостов"})
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": counter.errMsg})
 		}
 	}
 
-	for _, upd := range commentUpdates {
-		_, err = tx.NewUpdate().Model((*model.Post)(nil)).
-			Set("comments_count = GREATEST(comments_count - ?, 0)", upd.Count).
-			Where("id = ?", upd.PostID).
-			Exec(ctx)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка обновления счетчика комментариев"})
-		}
+	// Число реакций по типам
+	reactionCounts := tx.NewSelect().Model((*model.PostReaction)(nil)).
+		ColumnExpr("post_id, type, COUNT(*) AS cnt").
+		Where("user_id = ?", userID).
+		GroupExpr("post_id, type")
+	_, err = tx.NewUpdate().Model((*model.PostReactionCount)(nil)).
+		TableExpr("(?) AS counts", reactionCounts).
+		Set("count = GREATEST(post_reaction_count.count - counts.cnt, 0)").
+		Where("post_reaction_count.post_id = counts.post_id").
+		Where("post_reaction_count.type = counts.type").
+		Exec(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка обновления счетчика реакций"})
 	}
 
-	// Шаг 3. Удаляем записи из связанных таблиц, где пользователь является автором
-	associatedTables := []struct {
-		model interface{}
-		query string
+	// То же для чужих комментариев: лайки и ответы пользователя удалятся вместе с ним
+	commentCounters := []struct {
+		model     interface{}
+		column    string
+		commentFK string
+		errMsg    string
 	}{
-		{&model.PostLike{}, "user_id = ?"},
-		{&model.Comment{}, "user_id = ?"},
-		{&model.Repost{}, "user_id = ?"},
+		{(*model.CommentLike)(nil), "likes_count", "comment_id", "Ошибка обновления счетчика лайков комментариев"},
+		{(*model.Comment)(nil), "replies_count", "parent_id", "Ошибка обновления счетчика ответов"},
 	}
-	for _, table := range associatedTables {
-		if _, err = tx.NewDelete().Model(table.model).Where(table.query, userID).Exec(ctx); err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("Ошибка удаления данных из таблицы %T: %v", table.model, err),
-			})
-		}
-	}
-
-	// Шаг 4. Получаем ID всех постов пользователя
-	var postIDs []int
-	err = tx.NewSelect().Column("id").
-		Model((*model.Post)(nil)).
-		Where("user_id = ?", userID).
-		Scan(ctx, &postIDs)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка получения постов пользователя"})
-	}
-
-	if len(postIDs) > 0 {
-		// Удаляем данные, связанные с постами пользователя, из связанных таблиц
-		relatedTables := []struct {
-			model interface{}
-			query string
-		}{
-			{&model.PostLike{}, "post_id IN (?)"},
-			{&model.Comment{}, "post_id IN (?)"},
-			{&model.Repost{}, "original_post_id IN (?)"},
-			{&model.PostTag{}, "post_id IN (?)"},
-			{&model.Media{}, "post_id IN (?)"},
-		}
-		for _, table := range relatedTables {
-			if _, err = tx.NewDelete().Model(table.model).Where(table.query, bun.In(postIDs)).Exec(ctx); err != nil {
-				return c.JSON(http.StatusInternalServerError, map[string]string{
-					"error": fmt.Sprintf("Ошибка удаления из таблицы %T: %v", table.model, err),
-				})
-			}
-		}
-		// Удаляем посты пользователя
-		if _, err = tx.NewDelete().Model((*model.Post)(nil)).Where("user_id = ?", userID).Exec(ctx); err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка удаления постов пользователя"})
+	for _, counter := range commentCounters {
+		counts := tx.NewSelect().Model(counter.model).
+			ColumnExpr("? AS comment_id, COUNT(*) AS cnt", bun.Ident(counter.commentFK)).
+			Where("user_id = ?", userID).
+			GroupExpr("?", bun.Ident(counter.commentFK))
+		_, err = tx.NewUpdate().Model((*model.Comment)(nil)).
+			WhereAllWithDeleted().
+			TableExpr("(?) AS counts", counts).
+			Set("? = GREATEST(comment.? - counts.cnt, 0)", bun.Ident(counter.column), bun.Ident(counter.column)).
+			Where("comment.id = counts.comment_id").
+			Where("comment.user_id <> ?", userID).
+			Exec(ctx)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": counter.errMsg})
 		}
 	}
 
-	// Шаг 5. Удаляем самого пользователя
+	// Шаг 3. Удаляем пользователя: посты, реакции, комментарии, репосты, подписки,
+	// блокировки и пересылки удаляются каскадно внешними ключами
 	if _, err = tx.NewDelete().Model((*model.User)(nil)).Where("id = ?", userID).Exec(ctx); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка удаления пользователя"})
 	}
@@ -247,6 +259,7 @@ func (h *UserHandler) DeleteUser(c echo.Context) error {
 	if err = tx.Commit(); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка фиксации транзакции"})
 	}
+	h.invalidateUser(userID)
 
 	return c.NoContent(http.StatusNoContent)
 }
\ No newline at end of file
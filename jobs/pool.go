@@ -0,0 +1,411 @@
+package jobs
+
+import (
+	"api-service/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Handler обрабатывает задачу одного вида. Ошибка приводит к повтору с задержкой,
+// ошибка, обёрнутая в Permanent, — сразу к dead letter.
+type Handler func(ctx context.Context, job *model.Job) error
+
+// Настройки пула воркеров
+type Config struct {
+	Workers      int           // Количество параллельных воркеров
+	PollInterval time.Duration // Пауза между опросами пустой очереди
+	LockTimeout  time.Duration // Максимальное время выполнения; после него задачу может забрать другой воркер
+	BaseBackoff  time.Duration // Задержка перед первым повтором
+	MaxBackoff   time.Duration // Верхняя граница задержки
+	CronInterval time.Duration // Как часто проверять периодические задачи
+}
+
+// DefaultConfig возвращает настройки по умолчанию
+func DefaultConfig() Config {
+	return Config{
+		Workers:      4,
+		PollInterval: time.Second,
+		LockTimeout:  5 * time.Minute,
+		BaseBackoff:  5 * time.Second,
+		MaxBackoff:   time.Hour,
+		CronInterval: 15 * time.Second,
+	}
+}
+
+type schedule struct {
+	name    string
+	spec    string
+	kind    string
+	payload interface{}
+	cron    *CronSchedule
+}
+
+// Pool забирает задачи из таблицы jobs через SELECT ... FOR UPDATE SKIP LOCKED
+// и выполняет их зарегистрированными обработчиками
+type Pool struct {
+	db       *bun.DB
+	cfg      Config
+	workerID string
+
+	mu        sync.RWMutex
+	handlers  map[string]Handler
+	schedules []schedule
+
+	stop       chan struct{}
+	wg         sync.WaitGroup
+	jobCtx     context.Context
+	cancelJobs context.CancelFunc
+}
+
+// NewPool создаёт пул; воркеры запускаются методом Start
+func NewPool(db *bun.DB, cfg Config) *Pool {
+	defaults := DefaultConfig()
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaults.Workers
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaults.PollInterval
+	}
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = defaults.LockTimeout
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = defaults.BaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaults.MaxBackoff
+	}
+	if cfg.CronInterval <= 0 {
+		cfg.CronInterval = defaults.CronInterval
+	}
+
+	hostname, _ := os.Hostname()
+	jobCtx, cancel := context.WithCancel(context.Background())
+
+	return &Pool{
+		db:         db,
+		cfg:        cfg,
+		workerID:   fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		handlers:   make(map[string]Handler),
+		stop:       make(chan struct{}),
+		jobCtx:     jobCtx,
+		cancelJobs: cancel,
+	}
+}
+
+// Register регистрирует обработчик для задач вида kind
+func (p *Pool) Register(kind string, h Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[kind] = h
+}
+
+// Schedule добавляет периодическую задачу. Между репликами запуск дедуплицируется
+// через таблицу job_schedules, поэтому задача ставится в очередь ровно один раз за слот.
+func (p *Pool) Schedule(name, spec, kind string, payload interface{}) error {
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.schedules = append(p.schedules, schedule{name: name, spec: spec, kind: kind, payload: payload, cron: cron})
+	return nil
+}
+
+// Start запускает воркеры и планировщик периодических задач
+func (p *Pool) Start() {
+	if err := p.syncSchedules(context.Background()); err != nil {
+		log.Printf("Ошибка синхронизации расписаний задач: %v", err)
+	}
+
+	for i := 0; i < p.cfg.Workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+
+	p.wg.Add(1)
+	go p.runScheduler()
+
+	log.Printf("Пул фоновых задач запущен: %d воркеров", p.cfg.Workers)
+}
+
+// Shutdown прекращает забирать новые задачи и ждёт завершения выполняющихся.
+// Если ctx истекает раньше, контексты обработчиков отменяются, а задачи вернутся в очередь.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	close(p.stop)
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		p.cancelJobs()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// sleep ждёт d или сигнала остановки; возвращает false, если пул останавливается
+func (p *Pool) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-p.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+
+		job, err := p.fetch(context.Background())
+		if err != nil {
+			log.Printf("Ошибка получения задачи из очереди: %v", err)
+			if !p.sleep(p.cfg.PollInterval) {
+				return
+			}
+			continue
+		}
+		if job == nil {
+			if !p.sleep(p.cfg.PollInterval) {
+				return
+			}
+			continue
+		}
+
+		p.run(job)
+	}
+}
+
+// fetch блокирует одну готовую задачу и помечает её как выполняющуюся.
+// Задачи, зависшие в running дольше LockTimeout (упавший воркер), выдаются повторно.
+func (p *Pool) fetch(ctx context.Context) (*model.Job, error) {
+	job := new(model.Job)
+	now := time.Now()
+
+	err := p.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewSelect().
+			Model(job).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)",
+				model.JobStatusPending, now, model.JobStatusRunning, now.Add(-p.cfg.LockTimeout)).
+			OrderExpr("run_at ASC").
+			Limit(1).
+			For("UPDATE SKIP LOCKED").
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		job.Status = model.JobStatusRunning
+		job.Attempts++
+		job.LockedAt = now
+		job.LockedBy = p.workerID
+		_, err = tx.NewUpdate().
+			Model(job).
+			Column("status", "attempts", "locked_at", "locked_by").
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (p *Pool) run(job *model.Job) {
+	p.mu.RLock()
+	h, ok := p.handlers[job.Kind]
+	p.mu.RUnlock()
+
+	var err error
+	if !ok {
+		err = fmt.Errorf("нет обработчика для задачи %q", job.Kind)
+	} else {
+		ctx, cancel := context.WithTimeout(p.jobCtx, p.cfg.LockTimeout)
+		err = safeCall(ctx, h, job)
+		cancel()
+	}
+
+	// Результат записываем даже при отменённом контексте пула
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err == nil {
+		p.complete(ctx, job)
+		return
+	}
+
+	if isPermanent(err) || job.Attempts >= job.MaxAttempts {
+		log.Printf("Задача %d (%s) перемещена в dead letter после %d попыток: %v", job.ID, job.Kind, job.Attempts, err)
+		p.finish(ctx, job, model.JobStatusDead, err)
+		return
+	}
+
+	log.Printf("Задача %d (%s) завершилась ошибкой, попытка %d/%d: %v", job.ID, job.Kind, job.Attempts, job.MaxAttempts, err)
+	p.reschedule(ctx, job, err)
+}
+
+// safeCall вызывает обработчик, превращая панику в ошибку
+func safeCall(ctx context.Context, h Handler, job *model.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return h(ctx, job)
+}
+
+func (p *Pool) complete(ctx context.Context, job *model.Job) {
+	p.finish(ctx, job, model.JobStatusDone, nil)
+}
+
+func (p *Pool) finish(ctx context.Context, job *model.Job, status string, jobErr error) {
+	query := p.db.NewUpdate().
+		Model((*model.Job)(nil)).
+		Set("status = ?", status).
+		Set("finished_at = now()").
+		Set("locked_at = NULL").
+		Set("locked_by = NULL").
+		Where("id = ?", job.ID).
+		Where("locked_by = ?", p.workerID)
+	if jobErr != nil {
+		query = query.Set("last_error = ?", jobErr.Error())
+	}
+	if _, err := query.Exec(ctx); err != nil {
+		log.Printf("Ошибка обновления статуса задачи %d: %v", job.ID, err)
+	}
+}
+
+func (p *Pool) reschedule(ctx context.Context, job *model.Job, jobErr error) {
+	_, err := p.db.NewUpdate().
+		Model((*model.Job)(nil)).
+		Set("status = ?", model.JobStatusPending).
+		Set("run_at = ?", time.Now().Add(p.backoff(job.Attempts))).
+		Set("last_error = ?", jobErr.Error()).
+		Set("locked_at = NULL").
+		Set("locked_by = NULL").
+		Where("id = ?", job.ID).
+		Where("locked_by = ?", p.workerID).
+		Exec(ctx)
+	if err != nil {
+		log.Printf("Ошибка повторной постановки задачи %d: %v", job.ID, err)
+	}
+}
+
+// backoff — экспоненциальная задержка с джиттером до 20%
+func (p *Pool) backoff(attempt int) time.Duration {
+	d := p.cfg.BaseBackoff
+	for i := 1; i < attempt && d < p.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.cfg.MaxBackoff {
+		d = p.cfg.MaxBackoff
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+// syncSchedules записывает расписания в job_schedules. Если выражение изменилось,
+// время следующего запуска пересчитывается, иначе сохраняется уже запланированное.
+func (p *Pool) syncSchedules(ctx context.Context) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, s := range p.schedules {
+		row := &model.JobSchedule{Name: s.name, Spec: s.spec, NextRunAt: s.cron.Next(time.Now())}
+		_, err := p.db.NewInsert().
+			Model(row).
+			On("CONFLICT (name) DO UPDATE").
+			Set("spec = EXCLUDED.spec").
+			Set("next_run_at = CASE WHEN job_schedule.spec <> EXCLUDED.spec THEN EXCLUDED.next_run_at ELSE job_schedule.next_run_at END").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to sync schedule %q: %w", s.name, err)
+		}
+	}
+	return nil
+}
+
+func (p *Pool) runScheduler() {
+	defer p.wg.Done()
+
+	for p.sleep(p.cfg.CronInterval) {
+		p.mu.RLock()
+		schedules := append([]schedule(nil), p.schedules...)
+		p.mu.RUnlock()
+
+		for _, s := range schedules {
+			if err := p.fire(context.Background(), s); err != nil {
+				log.Printf("Ошибка запуска периодической задачи %s: %v", s.name, err)
+			}
+		}
+	}
+}
+
+// fire ставит периодическую задачу в очередь, если подошло её время. Сдвиг next_run_at
+// выполняется как compare-and-swap, поэтому из нескольких реплик задачу создаст только одна.
+func (p *Pool) fire(ctx context.Context, s schedule) error {
+	return p.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		row := new(model.JobSchedule)
+		err := tx.NewSelect().Model(row).Where("name = ?", s.name).Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if row.NextRunAt.After(now) {
+			return nil
+		}
+
+		res, err := tx.NewUpdate().
+			Model((*model.JobSchedule)(nil)).
+			Set("next_run_at = ?", s.cron.Next(now)).
+			Where("name = ?", s.name).
+			Where("next_run_at = ?", row.NextRunAt).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil // Слот уже забрала другая реплика
+		}
+
+		_, err = Enqueue(ctx, tx, s.kind, s.payload, EnqueueOptions{UniqueKey: "cron:" + s.name})
+		if errors.Is(err, ErrDuplicateJob) {
+			return nil // Предыдущий запуск ещё не завершился
+		}
+		return err
+	})
+}
@@ -0,0 +1,177 @@
+package jobs
+
+import (
+	"api-service/db"
+	"api-service/db/dbtest"
+	"api-service/model"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoffGrowsAndCaps(t *testing.T) {
+	p := NewPool(nil, Config{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	cases := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second}, // Упёрлись в MaxBackoff
+		{20, 10 * time.Second},
+	}
+	for _, tc := range cases {
+		got := p.backoff(tc.attempt)
+		// Джиттер добавляет не больше 20%
+		if got < tc.base || got > tc.base+tc.base/5 {
+			t.Errorf("backoff(%d) = %v, ожидалось от %v до %v", tc.attempt, got, tc.base, tc.base+tc.base/5)
+		}
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Fatal("Permanent(nil) должен возвращать nil")
+	}
+	cause := errors.New("bad payload")
+	err := fmt.Errorf("handler: %w", Permanent(cause))
+	if !isPermanent(err) {
+		t.Fatal("обёрнутая Permanent-ошибка не распознана")
+	}
+	if !errors.Is(err, cause) {
+		t.Fatal("Permanent должен сохранять исходную ошибку")
+	}
+	if isPermanent(cause) {
+		t.Fatal("обычная ошибка распознана как Permanent")
+	}
+}
+
+// newTestPool — пул на схеме с применёнными миграциями; воркеры не запускаются,
+// задачи выполняются вызовами runNext
+func newTestPool(t *testing.T) *Pool {
+	t.Helper()
+	conn := dbtest.Open(t)
+	m, err := db.NewMigrator(conn)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("миграции: %v", err)
+	}
+	return NewPool(conn, Config{Workers: 1, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+}
+
+// runNext забирает и выполняет одну готовую задачу, дождавшись окончания backoff
+func runNext(t *testing.T, p *Pool) {
+	t.Helper()
+	time.Sleep(10 * time.Millisecond)
+	job, err := p.fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if job == nil {
+		t.Fatal("в очереди нет готовой задачи")
+	}
+	p.run(job)
+}
+
+func loadJob(t *testing.T, p *Pool, id int64) *model.Job {
+	t.Helper()
+	job := new(model.Job)
+	if err := p.db.NewSelect().Model(job).Where("id = ?", id).Scan(context.Background()); err != nil {
+		t.Fatalf("загрузка задачи %d: %v", id, err)
+	}
+	return job
+}
+
+func TestPoolRetriesThenDeadLetters(t *testing.T) {
+	p := newTestPool(t)
+	ctx := context.Background()
+
+	calls := 0
+	p.Register("test.fail", func(ctx context.Context, job *model.Job) error {
+		calls++
+		return errors.New("boom")
+	})
+	job, err := Enqueue(ctx, p.db, "test.fail", nil, EnqueueOptions{MaxAttempts: 2})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	runNext(t, p)
+	got := loadJob(t, p, job.ID)
+	if got.Status != model.JobStatusPending || got.Attempts != 1 || got.LastError != "boom" || got.LockedBy != "" {
+		t.Fatalf("после первой ошибки: %+v; ожидалась pending с attempts=1", got)
+	}
+
+	runNext(t, p)
+	got = loadJob(t, p, job.ID)
+	if got.Status != model.JobStatusDead || got.Attempts != 2 || got.FinishedAt.IsZero() {
+		t.Fatalf("после последней попытки: %+v; ожидалась dead с attempts=2", got)
+	}
+	if calls != 2 {
+		t.Fatalf("обработчик вызван %d раз, ожидалось 2", calls)
+	}
+
+	// Из dead letter задачу можно вернуть в очередь вручную
+	if err := Retry(ctx, p.db, job.ID); err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	got = loadJob(t, p, job.ID)
+	if got.Status != model.JobStatusPending || got.Attempts != 0 || !got.FinishedAt.IsZero() {
+		t.Fatalf("после Retry: %+v; ожидалась pending с attempts=0", got)
+	}
+	if err := Retry(ctx, p.db, job.ID); err == nil {
+		t.Fatal("Retry задачи не из dead letter должен возвращать ошибку")
+	}
+}
+
+func TestPoolPermanentErrorSkipsRetries(t *testing.T) {
+	p := newTestPool(t)
+
+	p.Register("test.permanent", func(ctx context.Context, job *model.Job) error {
+		return Permanent(errors.New("bad payload"))
+	})
+	job, err := Enqueue(context.Background(), p.db, "test.permanent", nil, EnqueueOptions{MaxAttempts: 5})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	runNext(t, p)
+	got := loadJob(t, p, job.ID)
+	if got.Status != model.JobStatusDead || got.Attempts != 1 || got.LastError != "bad payload" {
+		t.Fatalf("%+v; ожидалась dead после первой попытки", got)
+	}
+}
+
+func TestPoolSucceedsAfterRetry(t *testing.T) {
+	p := newTestPool(t)
+
+	calls := 0
+	p.Register("test.flaky", func(ctx context.Context, job *model.Job) error {
+		calls++
+		if calls == 1 {
+			panic("первый вызов падает")
+		}
+		return nil
+	})
+	job, err := Enqueue(context.Background(), p.db, "test.flaky", nil, EnqueueOptions{})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	// Паника обработчика считается обычной ошибкой и ведёт к повтору
+	runNext(t, p)
+	if got := loadJob(t, p, job.ID); got.Status != model.JobStatusPending {
+		t.Fatalf("после паники: %+v; ожидалась pending", got)
+	}
+	runNext(t, p)
+	if got := loadJob(t, p, job.ID); got.Status != model.JobStatusDone || got.Attempts != 2 {
+		t.Fatalf("после повтора: %+v; ожидалась done с attempts=2", got)
+	}
+}
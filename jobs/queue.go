@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"api-service/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ErrDuplicateJob возвращается, если активная задача с тем же UniqueKey уже есть в очереди
+var ErrDuplicateJob = errors.New("задача с таким ключом уже в очереди")
+
+// Параметры постановки задачи в очередь
+type EnqueueOptions struct {
+	RunAt       time.Time // Не раньше этого момента (по умолчанию — сразу)
+	MaxAttempts int       // Сколько раз пытаться выполнить (по умолчанию 5)
+	UniqueKey   string    // Ключ дедупликации среди ожидающих и выполняющихся задач
+}
+
+// Enqueue ставит задачу в очередь. Принимает bun.IDB, чтобы задачу можно было
+// создать в той же транзакции, что и данные, которые она обрабатывает.
+func Enqueue(ctx context.Context, db bun.IDB, kind string, payload interface{}, opts EnqueueOptions) (*model.Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+	if payload == nil {
+		raw = json.RawMessage("{}")
+	}
+
+	job := &model.Job{
+		Kind:        kind,
+		Payload:     raw,
+		Status:      model.JobStatusPending,
+		MaxAttempts: opts.MaxAttempts,
+		UniqueKey:   opts.UniqueKey,
+		RunAt:       opts.RunAt,
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = 5
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+
+	query := db.NewInsert().Model(job).Returning("*")
+	if job.UniqueKey != "" {
+		// Условие совпадает с частичным уникальным индексом jobs_unique_key_active_idx
+		query = query.On("CONFLICT (unique_key) WHERE status IN (?, ?) DO NOTHING",
+			model.JobStatusPending, model.JobStatusRunning)
+	}
+
+	res, err := query.Exec(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue job %q: %w", kind, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return nil, ErrDuplicateJob
+	}
+	return job, nil
+}
+
+// Retry возвращает задачу из dead letter обратно в очередь с обнулёнными попытками
+func Retry(ctx context.Context, db bun.IDB, id int64) error {
+	res, err := db.NewUpdate().
+		Model((*model.Job)(nil)).
+		Set("status = ?", model.JobStatusPending).
+		Set("attempts = 0").
+		Set("run_at = now()").
+		Set("finished_at = NULL").
+		Where("id = ?", id).
+		Where("status = ?", model.JobStatusDead).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retry job %d: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("dead job %d not found", id)
+	}
+	return nil
+}
+
+// permanentError помечает ошибку, после которой повторять задачу бессмысленно
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent оборачивает ошибку обработчика, чтобы задача сразу ушла в dead letter без повторов
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
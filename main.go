@@ -1,9 +1,18 @@
 package main
 
 import (
+	"api-service/chatclient"
+	"api-service/config"
+	"api-service/counters"
 	"api-service/db"
 	"api-service/handler"
+	"api-service/jobs"
+	"api-service/model"
+	"api-service/publishing"
 	"api-service/router"
+	"api-service/storage"
+	"api-service/trash"
+	"api-service/utils"
 	"context"
 	"errors"
 	"log"
@@ -11,24 +20,27 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	authpb "api-service/proto/auth-service/proto" // Импорт для AuthService
-	chatpb "api-service/proto/chat-service/proto" // Импорт для ChatService
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/extra/bundebug"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
-const jwtSecret = "supersecretkey"
-
 // Реализация AuthService
 type AuthService struct {
-	DB *bun.DB
+	DB        *bun.DB
+	JWTSecret []byte                                   // Ключ проверки токенов
+	Users     *utils.TTLCache[int32, model.PublicUser] // Кэш публичных профилей
 	authpb.UnimplementedAuthServiceServer // Встраиваем UnimplementedAuthServiceServer
 }
 
@@ -40,9 +52,7 @@ type Claims struct {
 }
 
 // ParseToken парсит JWT-токен и возвращает claims
-func ParseToken(tokenString string) (*Claims, error) {
-	secretKey := []byte(jwtSecret)
-
+func ParseToken(tokenString string, secretKey []byte) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return secretKey, nil
 	})
@@ -76,7 +86,7 @@ func CheckUserExists(db *bun.DB, userID int32) (bool, error) {
 // Реализация метода ValidateToken
 func (s *AuthService) ValidateToken(ctx context.Context, req *authpb.ValidateTokenRequest) (*authpb.ValidateTokenResponse, error) {
 	// Парсим токен
-	claims, err := ParseToken(req.Token)
+	claims, err := ParseToken(req.Token, s.JWTSecret)
 	if err != nil {
 		return &authpb.ValidateTokenResponse{Valid: false}, nil
 	}
@@ -94,74 +104,160 @@ func (s *AuthService) ValidateToken(ctx context.Context, req *authpb.ValidateTok
 	}, nil
 }
 
+// openStorage создаёт хранилище файлов по настройкам: S3 (MinIO) или локальный каталог
+func openStorage(ctx context.Context, cfg *config.Config) (storage.Blob, error) {
+	if cfg.Storage.Driver == "s3" {
+		return storage.NewS3(ctx, storage.S3Config{
+			Endpoint:  cfg.Storage.S3.Endpoint,
+			AccessKey: cfg.Storage.S3.AccessKey,
+			SecretKey: cfg.Storage.S3.SecretKey,
+			Bucket:    cfg.Storage.S3.Bucket,
+			Region:    cfg.Storage.S3.Region,
+			UseSSL:    cfg.Storage.S3.UseSSL,
+		})
+	}
+	return storage.NewLocal(cfg.Storage.Dir)
+}
+
 func main() {
+	// Загружаем конфигурацию; при ошибке сразу завершаемся со списком проблем
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Ошибка конфигурации: %v", err)
+	}
+	log.Printf("Конфигурация:\n%s", cfg)
+
 	e := echo.New()
 
 	// Подключение к базе данных
-	dbConn := db.InitDB()
+	dbConn := db.InitDB(cfg.Database.DSN)
 	defer dbConn.Close()
 
 	// Инициализация bun.DB
 	bunDB := bun.NewDB(dbConn.DB, pgdialect.New())
 
-	// Миграции
-	db.RunMigrations(dbConn)
+	if cfg.Database.Debug {
+		// Логирование SQL-запросов
+		bunDB.AddQueryHook(bundebug.NewQueryHook(bundebug.WithVerbose(true)))
+	}
+
+	// Подкоманда migrate: выполняет миграции и завершает работу
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(context.Background(), bunDB, os.Args[2:]); err != nil {
+			log.Fatalf("Ошибка миграций: %v", err)
+		}
+		return
+	}
+
+	// Миграции при старте: реплики применяют их по очереди под advisory lock
+	if cfg.Database.AutoMigrate {
+		migrator, err := db.NewMigrator(bunDB)
+		if err != nil {
+			log.Fatalf("Ошибка загрузки миграций: %v", err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatalf("Ошибка миграций: %v", err)
+		}
+	}
+
+	// Запускаем пул фоновых задач
+	jobsConfig := jobs.DefaultConfig()
+	jobsConfig.Workers = cfg.Jobs.Workers
+	jobPool := jobs.NewPool(bunDB, jobsConfig)
+	if err := counters.Register(jobPool, bunDB, cfg.Jobs.ReconcileCounters); err != nil {
+		log.Fatalf("Ошибка расписания сверки счётчиков: %v", err)
+	}
+	if err := trash.Register(jobPool, bunDB, cfg.Jobs.PurgeTrash, cfg.Posts.TrashRetention); err != nil {
+		log.Fatalf("Ошибка расписания очистки корзины: %v", err)
+	}
+	if err := publishing.Register(jobPool, bunDB, cfg.Jobs.PublishScheduled); err != nil {
+		log.Fatalf("Ошибка расписания публикации постов: %v", err)
+	}
+	jobPool.Start()
 
 	// Создаём gRPC-сервер
 	grpcServer := grpc.NewServer()
-	authService := &AuthService{DB: bunDB} // Передаем bunDB
+	// Кэш публичных профилей: общий для gRPC и HTTP, чтобы изменения профиля сбрасывали его
+	userCache := utils.NewTTLCache[int32, model.PublicUser](time.Minute, 10000)
+	authService := &AuthService{DB: bunDB, JWTSecret: []byte(cfg.Auth.JWTSecret), Users: userCache} // Передаем bunDB
 	authpb.RegisterAuthServiceServer(grpcServer, authService)
 
+	// Протокол gRPC health: клиенты видят, что сервис готов принимать запросы
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	healthServer.SetServingStatus(authpb.AuthService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
 	// Запускаем gRPC-сервер
-	listener, err := net.Listen("tcp", ":50051")
+	listener, err := net.Listen("tcp", cfg.GRPC.Addr)
 	if err != nil {
 		log.Fatalf("Ошибка запуска gRPC-сервера: %v", err)
 	}
 
 	go func() {
-		log.Println("gRPC-сервер запущен на порту 50051")
+		log.Printf("gRPC-сервер запущен на %s", cfg.GRPC.Addr)
 		if err := grpcServer.Serve(listener); err != nil {
 			log.Fatalf("Ошибка работы gRPC-сервера: %v", err)
 		}
 	}()
 
-	// Подключение к Chat-service
-	chatConn, err := grpc.Dial(
-		"chat-service:50052",
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithDefaultCallOptions(
-			grpc.MaxCallRecvMsgSize(10*1024*1024), // 10 MB
-			grpc.MaxCallSendMsgSize(10*1024*1024), // 10 MB
-		),
-	)
+	// Шлюз чатов проверяет политику взаимодействия тем же RPC CanInteract, что и
+	// chat-service, поэтому подключаемся к собственному gRPC-серверу
+	_, grpcPort, _ := net.SplitHostPort(listener.Addr().String())
+	authConn, err := grpc.NewClient(net.JoinHostPort("localhost", grpcPort), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Не удалось создать клиент AuthService: %v", err)
+	}
+	defer authConn.Close()
+
+	// Подключение к Chat-service: таймауты, повторы, circuit breaker и проверка здоровья
+	chatConfig := chatclient.DefaultConfig(cfg.Chat.Addr)
+	chatConfig.DefaultTimeout = cfg.Chat.Timeout
+	chatClient, err := chatclient.New(chatConfig)
 	if err != nil {
-		log.Fatalf("Не удалось подключиться к Chat-service: %v", err)
+		log.Fatalf("Не удалось создать клиент Chat-service: %v", err)
 	}
-	defer chatConn.Close()
+	defer chatClient.Close()
 
-	chatServiceClient := chatpb.NewChatServiceClient(chatConn)
+	// Хранилище вложений чатов
+	blobStorage, err := openStorage(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Ошибка подключения к хранилищу файлов: %v", err)
+	}
 
 	// Создаём обработчики
 	userHandler := &handler.UserHandler{
 		DB:                bunDB,
-		ChatServiceClient: chatServiceClient, // Используем правильный клиент
+		AuthServiceClient: authpb.NewAuthServiceClient(authConn),
+		ChatServiceClient: chatClient, // Используем правильный клиент
+		UserCache:         userCache,
+		Storage:           blobStorage,
+		URLSigner:         storage.NewSigner([]byte(cfg.Storage.URLSecret), cfg.Storage.URLTTL),
+		JWTSecret:         []byte(cfg.Auth.JWTSecret),
+		TokenTTL:          cfg.Auth.TokenTTL,
+		WSAllowedOrigins:  cfg.Chat.AllowedOrigins,
 	}
 
 	postHandler := &handler.PostHandler{
-		DB: bunDB,
+		DB:                bunDB,
+		ChatServiceClient: chatClient,
+		AuthServiceClient: userHandler.AuthServiceClient,
+		EditWindow:        cfg.Posts.EditWindow,
+		TrashRetention:    cfg.Posts.TrashRetention,
+		CommentMaxDepth:   cfg.Comments.MaxDepth,
+		Reactions:         cfg.Posts.Reactions,
 	}
 
 	// Настройка маршрутов
-	router.SetupRoutes(e, userHandler, postHandler, bunDB)
+	router.SetupRoutes(e, userHandler, postHandler, bunDB, []byte(cfg.Auth.JWTSecret), chatClient.Available)
 
 	// Запуск HTTP-сервера с поддержкой graceful shutdown
 	server := &http.Server{
-		Addr:    ":8080",
+		Addr:    cfg.HTTP.Addr,
 		Handler: e,
 	}
 
 	go func() {
-		log.Println("HTTP-сервер запущен на порту 8080")
+		log.Printf("HTTP-сервер запущен на %s", cfg.HTTP.Addr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Ошибка работы HTTP-сервера: %v", err)
 		}
@@ -169,7 +265,7 @@ func main() {
 
 	// Ожидаем сигнал завершения
 	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
 
 	// Корректно завершаем работу
@@ -177,10 +273,20 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Ошибка при завершении HTTP-сервера: %v", err)
+		// Остальные шаги всё равно выполняем: пул задач должен вернуть незавершённое в очередь
+		log.Printf("Ошибка при завершении HTTP-сервера: %v", err)
+	}
+
+	// Дожидаемся выполняющихся фоновых задач; незавершённые вернутся в очередь
+	log.Println("Останавливаем пул фоновых задач...")
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer drainCancel()
+	if err := jobPool.Shutdown(drainCtx); err != nil {
+		log.Printf("Не все фоновые задачи успели завершиться: %v", err)
 	}
 
 	log.Println("Завершаем работу gRPC-сервера...")
+	healthServer.Shutdown() // Клиенты перестают направлять новые запросы
 	grpcServer.GracefulStop()
 	log.Println("Сервер успешно завершил работу")
 }
\ No newline at end of file
@@ -77,7 +77,7 @@ import (
     "github.com/uptrace/bun"
 )
 
-func JWTMiddleware(db *bun.DB) echo.MiddlewareFunc {
+func JWTMiddleware(db *bun.DB, secret []byte) echo.MiddlewareFunc {
     return func(next echo.HandlerFunc) echo.HandlerFunc {
         return func(c echo.Context) error {
             authHeader := c.Request().Header.Get("Authorization")
@@ -87,7 +87,7 @@ func JWTMiddleware(db *bun.DB) echo.MiddlewareFunc {
 
             tokenString := authHeader[len("Bearer "):]
             token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-                return []byte("supersecretkey"), nil
+                return secret, nil
             })
 
             if err != nil || !token.Valid {
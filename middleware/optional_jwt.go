@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+// OptionalJWTMiddleware для публичных маршрутов: при валидном токене сохраняет
+// user_id в контексте, как JWTMiddleware, а без токена или с невалидным токеном
+// пропускает запрос анонимно. Так закрытые посты видны подписчикам и без
+// отдельных защищённых маршрутов.
+func OptionalJWTMiddleware(secret []byte) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			tokenString, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+			if !ok || tokenString == "" {
+				return next(c)
+			}
+
+			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+				return secret, nil
+			})
+			if err != nil || !token.Valid {
+				return next(c)
+			}
+
+			claims, ok := token.Claims.(jwt.MapClaims)
+			if !ok {
+				return next(c)
+			}
+			if userID, ok := claims["user_id"].(float64); ok { // JWT возвращает числа как float64
+				c.Set("user_id", int(userID))
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"api-service/model"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+// RequireRole пропускает только пользователей с указанной ролью. Роль читается
+// из базы на каждый запрос, а не из токена, поэтому её отзыв действует сразу.
+// Должен стоять после JWTMiddleware.
+func RequireRole(db *bun.DB, role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, ok := c.Get("user_id").(int)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Не удалось получить ID пользователя"})
+			}
+
+			var userRole string
+			err := db.NewSelect().
+				Model((*model.User)(nil)).
+				Column("role").
+				Where("id = ?", userID).
+				Scan(c.Request().Context(), &userRole)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Ошибка проверки прав"})
+			}
+			if userRole != role {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "Недостаточно прав"})
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Через сколько клиенту стоит повторить запрос к недоступному сервису
+const serviceRetryAfter = 10 * time.Second
+
+// RequireService отвечает 503, пока зависимый сервис недоступен,
+// чтобы запросы не висели до таймаута, а остальные маршруты продолжали работать
+func RequireService(name string, available func() bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !available() {
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(serviceRetryAfter.Seconds())))
+				return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": name + " временно недоступен"})
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// TokenFromQuery переносит токен из параметра ?token= в заголовок Authorization.
+// Браузерный WebSocket API не умеет задавать заголовки, поэтому подключается только к WS-маршрутам.
+func TokenFromQuery(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		if req.Header.Get("Authorization") == "" {
+			if token := c.QueryParam("token"); token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		return next(c)
+	}
+}
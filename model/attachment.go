@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Файл, загруженный в чат. Содержимое лежит в хранилище, здесь только метаданные.
+type ChatAttachment struct {
+	ID                   string    `json:"id" bun:",pk"`
+	ChatID               string    `json:"chat_id" bun:",notnull"`
+	UploaderID           int       `json:"uploader_id" bun:",notnull"`
+	FileName             string    `json:"file_name" bun:",notnull"`
+	ContentType          string    `json:"content_type" bun:",notnull"`
+	Size                 int64     `json:"size" bun:",notnull"`
+	Width                int       `json:"width,omitempty"`
+	Height               int       `json:"height,omitempty"`
+	StorageKey           string    `json:"-" bun:",notnull"`
+	ThumbnailKey         string    `json:"-" bun:",nullzero"`
+	ThumbnailContentType string    `json:"-" bun:",nullzero"`
+	CreatedAt            time.Time `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,37 @@
+package model
+
+// Структура для запроса на создание чата
+type CreateChatRequest struct {
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Participants []int  `json:"participants"` // ID пользователей, создатель добавляется автоматически
+	IsGroup      bool   `json:"is_group"`
+}
+
+// Структура для запроса на обновление чата
+type UpdateChatRequest struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+}
+
+// Структура для запроса на отправку сообщения
+type SendMessageRequest struct {
+	Content  string   `json:"content"`
+	FileURLs []string `json:"file_urls,omitempty"`
+}
+
+// Структура для запроса на редактирование сообщения
+type EditMessageRequest struct {
+	Content string `json:"content"`
+}
+
+// Структура для запроса на добавление участника
+type AddParticipantRequest struct {
+	UserID int `json:"user_id"`
+}
+
+// Структура для запроса на установку реакции
+type SetReactionRequest struct {
+	Reaction string `json:"reaction"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы фоновых задач
+const (
+	JobStatusPending = "pending" // Ожидает выполнения
+	JobStatusRunning = "running" // Взята воркером
+	JobStatusDone    = "done"    // Успешно выполнена
+	JobStatusDead    = "dead"    // Исчерпала попытки (dead letter)
+)
+
+// Структура фоновой задачи
+type Job struct {
+	ID          int64           `json:"id" bun:",pk,autoincrement"`
+	Kind        string          `json:"kind" bun:",notnull"`
+	Payload     json.RawMessage `json:"payload" bun:"type:jsonb,notnull,default:'{}'"`
+	Status      string          `json:"status" bun:",notnull,default:'pending'"`
+	Attempts    int             `json:"attempts" bun:",notnull,default:0"`
+	MaxAttempts int             `json:"max_attempts" bun:",notnull,default:5"`
+	UniqueKey   string          `json:"unique_key,omitempty" bun:",nullzero"`
+	LastError   string          `json:"last_error,omitempty" bun:",nullzero"`
+	RunAt       time.Time       `json:"run_at" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"`
+	LockedAt    time.Time       `json:"locked_at,omitempty" bun:"type:timestamptz,nullzero"`
+	LockedBy    string          `json:"locked_by,omitempty" bun:",nullzero"`
+	CreatedAt   time.Time       `json:"created_at" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"`
+	FinishedAt  time.Time       `json:"finished_at,omitempty" bun:"type:timestamptz,nullzero"`
+}
+
+// Расписание периодической задачи (cron)
+type JobSchedule struct {
+	Name      string    `json:"name" bun:",pk"`
+	Spec      string    `json:"spec" bun:",notnull"`
+	NextRunAt time.Time `json:"next_run_at" bun:"type:timestamptz,notnull"`
+}
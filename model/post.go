@@ -1,6 +1,24 @@
 package model
 
-import "time"
+import (
+	"context"
+	"time"
+)
+
+// Статусы поста
+const (
+	PostStatusDraft     = "draft"     // Виден только автору
+	PostStatusScheduled = "scheduled" // Будет опубликован в publish_at
+	PostStatusPublished = "published"
+)
+
+// Кто может комментировать пост и отвечать на него
+const (
+	CommentsPolicyEveryone  = "everyone"
+	CommentsPolicyFollowers = "followers" // Подписчики автора
+	CommentsPolicyMentioned = "mentioned" // Упомянутые в посте через @имя
+	CommentsPolicyNobody    = "nobody"    // Комментарии отключены
+)
 
 // Структура поста
 type Post struct {
@@ -13,15 +31,59 @@ type Post struct {
 	Tags      []PostTag    `json:"tags,omitempty" bun:"rel:has-many,join:id=post_id"`
 	Media     []Media  `json:"media,omitempty" bun:"rel:has-many,join:id=post_id"`
 	Comments []Comment `json:"comments,omitempty" bun:"rel:has-many,join:id=post_id"`
-	LikesCount    int `json:"likes_count"`
+	LikesCount    int `json:"likes_count"` // Реакции всех типов
 	RepostsCount  int `json:"reposts_count"`
 	CommentsCount int `json:"comments_count"`
+	SharesCount   int `json:"shares_count"`
+	QuotesCount   int `json:"quotes_count"`
+	Version       int `json:"version" bun:",notnull,default:1"` // Растёт при каждом изменении, отдаётся в ETag
+	Status        string     `json:"status" bun:",notnull,default:'published'"`
+	PublishAt     *time.Time `json:"publish_at,omitempty" bun:"type:timestamptz"`   // Когда опубликовать отложенный пост
+	PublishedAt   *time.Time `json:"published_at,omitempty" bun:"type:timestamptz"` // Когда пост стал виден всем
+	IsQuote       bool        `json:"is_quote" bun:",notnull,default:false"`
+	QuotedPostID  *int        `json:"quoted_post_id,omitempty"` // Обнуляется, когда оригинал удалён окончательно
+	Quote         *QuotedPost `json:"quote,omitempty" bun:"-"`
+	Reactions     map[string]int `json:"reactions,omitempty" bun:"-"` // Число реакций по типам
+	InReplyToID    *int `json:"in_reply_to_id,omitempty"`                 // Пост, на который это ответ
+	ConversationID int  `json:"conversation_id,omitempty" bun:",nullzero"` // Корневой пост ветки
+	CommentsPolicy  string `json:"comments_policy" bun:",notnull,default:'everyone'"`
+	PinnedCommentID *int   `json:"pinned_comment_id,omitempty"` // Закреплённый автором комментарий
+	Edited        bool       `json:"edited" bun:"-"`
+	EditedAt      *time.Time `json:"edited_at,omitempty" bun:"type:timestamptz"`
+	// Мягкое удаление: bun скрывает такие посты во всех запросах через модель.
+	// Счётчики обновляются с WhereAllWithDeleted, чтобы пост восстановился с верными значениями
+	DeletedAt     time.Time  `json:"-" bun:"type:timestamptz,soft_delete,nullzero"`
+}
+
+// AfterScanRow выставляет отметку о редактировании
+func (p *Post) AfterScanRow(ctx context.Context) error {
+	p.Edited = p.EditedAt != nil
+	return nil
+}
+
+// Почему вместо поста (оригинала цитаты или поста в ветке) отдаётся заглушка
+const (
+	TombstoneDeleted     = "deleted"     // Пост удалён
+	TombstoneUnavailable = "unavailable" // Пост скрыт от читателя: закрытый аккаунт или блокировка
+)
+
+// QuotedPost — оригинал внутри цитаты. Если его нельзя показать, вместо поста
+// отдаётся заглушка с причиной
+type QuotedPost struct {
+	Post      *Post  `json:"post,omitempty"`
+	Tombstone string `json:"tombstone,omitempty"`
 }
 
+// Допустимые типы медиафайлов
+const (
+	MediaTypeImage = "image"
+	MediaTypeVideo = "video"
+)
+
 // Структура медиафайлов
 type Media struct {
 	ID     int    `json:"id,omitempty" bun:",pk,autoincrement"` // Идентификатор медиа (может быть пустым при загрузке)
-	PostID int    `json:"post_id,omitempty"`                   // ID поста, к которому прикреплено медиа
+	PostID int    `json:"post_id,omitempty" bun:",notnull"`    // ID поста, к которому прикреплено медиа
 	URL    string `json:"url" validate:"required"`             // Ссылка на медиафайл
 	Type   string `json:"type" validate:"required"`            // Тип медиафайла: "image" или "video"
 }
@@ -34,18 +96,43 @@ type Tag struct {
 
 // Связь постов и тегов
 type PostTag struct {
-    PostID int `bun:"post_id"`
-    TagID  int `bun:"tag_id"`
+    PostID int `bun:"post_id,notnull,unique:post_tags_pair"`
+    TagID  int `bun:"tag_id,notnull,unique:post_tags_pair"`
+}
+
+
+// Реакция по умолчанию: её ставит POST /posts/:id/like, в неё перенесены старые лайки
+const ReactionLike = "like"
+
+// Реакция на пост. У пользователя одна реакция на пост, тип можно сменить
+type PostReaction struct {
+	ID        int       `json:"id" bun:",pk,autoincrement"`
+	PostID    int       `json:"post_id" bun:",notnull,unique:post_reactions_pair"`
+	UserID    int       `json:"user_id" bun:",notnull,unique:post_reactions_pair"`
+	Type      string    `json:"type" bun:",notnull"`
+	CreatedAt time.Time `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+	User      *User     `json:"-" bun:"rel:belongs-to,join:user_id=id"`
+}
+
+// Упомянутый в посте пользователь. Упоминания @имя разбираются при сохранении
+// поста, поэтому смена имени потом на них не влияет
+type PostMention struct {
+	PostID int `bun:",pk"`
+	UserID int `bun:",pk"`
 }
 
+// Число реакций одного типа на пост; меняется вместе с реакциями
+type PostReactionCount struct {
+	PostID int    `bun:",pk"`
+	Type   string `bun:",pk"`
+	Count  int    `bun:",notnull"`
+}
 
-// Структура для лайков
-type PostLike struct {
-	ID     int    `json:"id" bun:",pk,autoincrement"`
-	PostID int    `json:"post_id" bun:",notnull"`
-	UserID int    `json:"user_id" bun:",notnull"`
-	Post   *Post  `json:"post,omitempty" bun:"rel:belongs-to,join:post_id=id"`
-	User   *User  `json:"user,omitempty" bun:"rel:belongs-to,join:user_id=id"`
+// Пользователь, отреагировавший на пост
+type PostReactor struct {
+	User      PublicUser `json:"user"`
+	Type      string     `json:"type"`
+	ReactedAt time.Time  `json:"reacted_at"`
 }
 
 // Структура для комментариев
@@ -55,20 +142,82 @@ type Comment struct {
 	UserID    int       `json:"user_id" bun:",notnull"`
 	Content   string    `json:"content" bun:",notnull"`
 	CreatedAt time.Time `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+	Edited    bool       `json:"edited" bun:"-"`
+	EditedAt  *time.Time `json:"edited_at,omitempty" bun:"type:timestamptz"`
+	DeletedAt time.Time  `json:"-" bun:"type:timestamptz,soft_delete,nullzero"`
+	DeletedBy *int       `json:"deleted_by,omitempty"` // Автор комментария или автор поста
+	ParentID     *int        `json:"parent_id,omitempty"`                     // Комментарий, на который это ответ
+	Depth        int         `json:"depth" bun:",notnull,default:0"`          // 0 — комментарий к посту
+	RepliesCount int         `json:"replies_count" bun:",notnull,default:0"` // Число прямых ответов
+	LikesCount   int         `json:"likes_count" bun:",notnull,default:0"`
+	HiddenAt     *time.Time  `json:"hidden_at,omitempty" bun:"type:timestamptz"` // Скрыт автором поста
+	Pinned       bool        `json:"pinned,omitempty" bun:"-"`
+	Deleted      bool        `json:"deleted,omitempty" bun:"-"` // Удалённый комментарий с ответами: без текста и автора
 	Post      *Post     `json:"post,omitempty" bun:"rel:belongs-to,join:post_id=id"`
-	User      *User     `json:"user,omitempty" bun:"rel:belongs-to,join:user_id=id"`
+	User      *User     `json:"-" bun:"rel:belongs-to,join:user_id=id"` // Наружу отдаётся только Author
+	Author    *PublicUser `json:"author,omitempty" bun:"-"`
+}
+
+// Порядок комментариев в выдаче
+const (
+	CommentSortNewest = "newest"
+	CommentSortOldest = "oldest"
+	CommentSortTop    = "top" // Больше лайков — выше
+)
+
+// Лайк комментария
+type CommentLike struct {
+	ID        int       `json:"id" bun:",pk,autoincrement"`
+	CommentID int       `json:"comment_id" bun:",notnull,unique:comment_likes_pair"`
+	UserID    int       `json:"user_id" bun:",notnull,unique:comment_likes_pair"`
+	CreatedAt time.Time `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+	User      *User     `json:"-" bun:"rel:belongs-to,join:user_id=id"`
+}
+
+// Пользователь, лайкнувший комментарий
+type CommentLiker struct {
+	User    PublicUser `json:"user"`
+	LikedAt time.Time  `json:"liked_at"`
+}
+
+// Страница комментариев
+type CommentPage struct {
+	Comments   []Comment `json:"comments"`
+	NextCursor string    `json:"next_cursor,omitempty"` // Передаётся в ?cursor= для следующей страницы
+}
+
+// AfterScanRow выставляет отметку о редактировании
+func (c *Comment) AfterScanRow(ctx context.Context) error {
+	c.Edited = c.EditedAt != nil
+	return nil
 }
 
 // Структура для репостов
 type Repost struct {
     ID             int       `json:"id" bun:",pk,autoincrement"`
-    OriginalPostID int       `json:"original_post_id" bun:",notnull"`
-    UserID         int       `json:"user_id" bun:",notnull"`
+    OriginalPostID int       `json:"original_post_id" bun:",notnull,unique:reposts_pair"`
+    UserID         int       `json:"user_id" bun:",notnull,unique:reposts_pair"`
     OriginalPost   *Post     `json:"original_post,omitempty" bun:"rel:belongs-to,join:original_post_id=id"`
     User           *User     `json:"user,omitempty" bun:"rel:belongs-to,join:user_id=id"`
     CreatedAt      time.Time `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
 }
 
+// Пересылка поста в чат
+type PostShare struct {
+	ID        int       `json:"id" bun:",pk,autoincrement"`
+	PostID    int       `json:"post_id" bun:",notnull"`
+	UserID    int       `json:"user_id" bun:",notnull"`
+	ChatID    string    `json:"chat_id" bun:",notnull"`
+	MessageID string    `json:"message_id"`
+	CreatedAt time.Time `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// Структура для запроса на пересылку поста в чаты
+type SharePostRequest struct {
+	ChatIDs []string `json:"chat_ids"`
+	Comment string   `json:"comment,omitempty"` // Текст сообщения к посту
+}
+
 // Структура для запроса на создание поста
 type CreatePostRequest struct {
 	UserID  int    	 `json:"user_id" bun:",notnull"`
@@ -77,6 +226,70 @@ type CreatePostRequest struct {
 	Content string   `json:"content" validate:"required"`
 	Tags    []string `json:"tags,omitempty"`
 	Media   []Media  `json:"media,omitempty"` // Используем объединенную структуру
+	Status    string     `json:"status,omitempty"`     // По умолчанию published, а с publish_at — scheduled
+	PublishAt *time.Time `json:"publish_at,omitempty"` // Обязателен для scheduled
+	QuotedPostID *int    `json:"quoted_post_id,omitempty"` // Цитируемый пост
+	InReplyToID  *int    `json:"in_reply_to_id,omitempty"` // Пост, на который это ответ
+	CommentsPolicy string `json:"comments_policy,omitempty"` // По умолчанию everyone
+}
+
+// Один пост цепочки в CreateThreadRequest
+type ThreadPostRequest struct {
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags,omitempty"`
+	Media   []Media  `json:"media,omitempty"`
+}
+
+// Структура для запроса на публикацию цепочки: каждый пост отвечает на предыдущий,
+// первый — на InReplyToID, если он задан. Статус общий для всей цепочки
+type CreateThreadRequest struct {
+	InReplyToID *int                `json:"in_reply_to_id,omitempty"`
+	Status      string              `json:"status,omitempty"`
+	PublishAt   *time.Time          `json:"publish_at,omitempty"`
+	CommentsPolicy string           `json:"comments_policy,omitempty"` // Для всех постов цепочки
+	Posts       []ThreadPostRequest `json:"posts"`
+}
+
+// ThreadNode — пост в ветке вместе с ответами на него. Вместо удалённого
+// или скрытого поста отдаётся заглушка, но ответы на него остаются
+type ThreadNode struct {
+	Post        *Post        `json:"post,omitempty"`
+	Tombstone   string       `json:"tombstone,omitempty"`
+	Replies     []ThreadNode `json:"replies,omitempty"`
+	MoreReplies bool         `json:"more_replies,omitempty"` // Есть ответы, не вошедшие в выдачу
+}
+
+// Ответ GET /posts/:id/thread
+type PostThread struct {
+	Ancestors []ThreadNode `json:"ancestors"` // От корня ветки к родителю поста
+	Post      *Post        `json:"post"`
+	Replies   []ThreadNode `json:"replies"`
+	NextAfter *int         `json:"next_after,omitempty"` // Значение ?after= для следующей страницы ответов
+}
+
+// Операции над тегами в PATCH: либо set (замена целиком), либо add/remove
+type TagsPatch struct {
+	Set    *[]string `json:"set,omitempty"`
+	Add    []string  `json:"add,omitempty"`
+	Remove []string  `json:"remove,omitempty"`
+}
+
+// Операции над медиа в PATCH: либо set (замена целиком), либо add/remove
+type MediaPatch struct {
+	Set    *[]Media `json:"set,omitempty"`
+	Add    []Media  `json:"add,omitempty"`
+	Remove []int    `json:"remove,omitempty"` // ID медиафайлов
+}
+
+// Структура для запроса PATCH /posts/:id: изменяются только переданные поля
+type PatchPostRequest struct {
+	Title   *string     `json:"title,omitempty"`
+	Content *string     `json:"content,omitempty"`
+	Tags    *TagsPatch  `json:"tags,omitempty"`
+	Media   *MediaPatch `json:"media,omitempty"`
+	Status    *string    `json:"status,omitempty"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
 }
 
 // Структура для запроса на обновление поста
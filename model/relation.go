@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// Настройки личных сообщений пользователя
+const (
+	DMPolicyEveryone  = "everyone"  // Писать может любой
+	DMPolicyFollowers = "followers" // Только подписчики
+	DMPolicyNobody    = "nobody"    // Личные сообщения отключены
+)
+
+// Подписка на пользователя. Подписка на закрытый аккаунт ждёт подтверждения (Accepted = false).
+type Follow struct {
+	ID         int       `json:"id" bun:",pk,autoincrement"`
+	FollowerID int       `json:"follower_id" bun:",notnull,unique:follows_pair"`
+	FolloweeID int       `json:"followee_id" bun:",notnull,unique:follows_pair"`
+	Accepted   bool      `json:"accepted" bun:",notnull"`
+	CreatedAt  time.Time `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+	Follower   *User     `json:"-" bun:"rel:belongs-to,join:follower_id=id"`
+}
+
+// Блокировка пользователя
+type UserBlock struct {
+	ID        int       `json:"id" bun:",pk,autoincrement"`
+	BlockerID int       `json:"blocker_id" bun:",notnull,unique:user_blocks_pair"`
+	BlockedID int       `json:"blocked_id" bun:",notnull,unique:user_blocks_pair"`
+	CreatedAt time.Time `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// Снимок поста на определённую версию
+type PostRevision struct {
+	ID        int64     `json:"id" bun:",pk,autoincrement"`
+	PostID    int       `json:"post_id" bun:",notnull,unique:post_revisions_version"`
+	Version   int       `json:"version" bun:",notnull,unique:post_revisions_version"`
+	Title     string    `json:"title" bun:",notnull"`
+	Content   string    `json:"content"`
+	Tags      []string  `json:"tags" bun:"type:jsonb,notnull,default:'[]'"`
+	Media     []Media   `json:"media" bun:"type:jsonb,notnull,default:'[]'"`
+	EditorID  int       `json:"editor_id,omitempty" bun:",nullzero"` // NULL, если редактор удалён
+	CreatedAt time.Time `json:"created_at" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"`
+}
+
+// Изменение одного текстового поля между ревизиями
+type FieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Строка построчного диффа: op — equal, insert или delete
+type LineDiff struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Отличия ревизии от предыдущей
+type RevisionDiff struct {
+	Title        *FieldChange `json:"title,omitempty"`
+	Content      []LineDiff   `json:"content,omitempty"`
+	TagsAdded    []string     `json:"tags_added,omitempty"`
+	TagsRemoved  []string     `json:"tags_removed,omitempty"`
+	MediaAdded   []Media      `json:"media_added,omitempty"`
+	MediaRemoved []Media      `json:"media_removed,omitempty"`
+}
+
+// Ревизия с отличиями от предыдущей (для первой ревизии diff пустой)
+type PostRevisionWithDiff struct {
+	PostRevision
+	Diff *RevisionDiff `json:"diff,omitempty"`
+}
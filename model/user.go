@@ -2,18 +2,24 @@ package model
 
 import "time"
 
+// Роль администратора: доступ к служебным маршрутам /admin
+const RoleAdmin = "admin"
+
 // Структура пользователя
 type User struct {
-    ID        int32     `bun:"id,pk,autoincrement"`
-    Name      string    `bun:"name,notnull"`
-    Email     string    `bun:"email,unique,notnull"`
-    Password  string    `bun:"password,notnull"`
-    Avatar    string    `bun:"avatar"`
-    Status    string    `bun:"status"`
-    CreatedAt time.Time `bun:"created_at,default:current_timestamp"`
-    LastSeen  time.Time `bun:"last_seen"`
-    Role      string    `bun:"role"`
-    Bio       string    `bun:"bio"`
+    ID          int32     `bun:"id,pk,autoincrement"`
+    Name        string    `bun:"name,notnull"`
+    Email       string    `bun:"email,unique,notnull"`
+    Password    string    `bun:"password,notnull"`
+    Avatar      string    `bun:"avatar"`
+    Status      string    `bun:"status"`
+    CreatedAt   time.Time `bun:"created_at,default:current_timestamp"`
+    LastSeen    time.Time `bun:"last_seen"`
+    Role        string    `bun:"role"`
+    Bio         string    `bun:"bio"`
+    IsPrivate   bool      `bun:"is_private,notnull"`
+    DMPolicy    string    `bun:"dm_policy,nullzero,notnull,default:'everyone'"`
+    SuspendedAt time.Time `bun:"suspended_at,nullzero"`
 }
 
 // Структура для запроса на создание пользователя
@@ -32,6 +38,8 @@ type UpdateUserRequest struct {
 	Password string `json:"password,omitempty" validate:"omitempty,min=10"` // Пароль
 	Avatar   string `json:"avatar,omitempty"`                       // Ссылка на аватар
 	Status   string `json:"status,omitempty"`                       // Изменение статуса
+	IsPrivate *bool `json:"is_private,omitempty"`                   // Закрытый аккаунт
+	DMPolicy string `json:"dm_policy,omitempty"`                    // Кто может писать в личные сообщения
 }
 
 // Структура для запроса на логин
@@ -39,3 +47,27 @@ type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 }
+
+// Публичный профиль пользователя: без email, пароля и роли
+type PublicUser struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	Avatar    string    `json:"avatar"`
+	Status    string    `json:"status"`
+	Bio       string    `json:"bio"`
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Public возвращает публичный профиль пользователя
+func (u *User) Public() PublicUser {
+	return PublicUser{
+		ID:        u.ID,
+		Name:      u.Name,
+		Avatar:    u.Avatar,
+		Status:    u.Status,
+		Bio:       u.Bio,
+		CreatedAt: u.CreatedAt,
+		LastSeen:  u.LastSeen,
+	}
+}
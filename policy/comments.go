@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"api-service/model"
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/uptrace/bun"
+)
+
+// CanComment решает, может ли userID комментировать пост и отвечать на него
+// по настройке post.CommentsPolicy. Автор поста может всегда.
+// У post должны быть заполнены ID, UserID и CommentsPolicy.
+func CanComment(ctx context.Context, db bun.IDB, userID int, post *model.Post) (bool, error) {
+	if userID == post.UserID {
+		return true, nil
+	}
+
+	switch post.CommentsPolicy {
+	case model.CommentsPolicyEveryone, "":
+		return true, nil
+	case model.CommentsPolicyFollowers:
+		return db.NewSelect().
+			Model((*model.Follow)(nil)).
+			Where("follower_id = ? AND followee_id = ?", userID, post.UserID).
+			Where("accepted = TRUE").
+			Exists(ctx)
+	case model.CommentsPolicyMentioned:
+		return db.NewSelect().
+			Model((*model.PostMention)(nil)).
+			Where("post_id = ? AND user_id = ?", post.ID, userID).
+			Exists(ctx)
+	default:
+		return false, nil
+	}
+}
+
+// MentionedNames возвращает имена, упомянутые в text как @имя, в нижнем регистре
+// и без повторов. Имя продолжается, пока идут буквы, цифры и _, поэтому в
+// "@ann, @anna" упомянуты ann и anna
+func MentionedNames(text string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for {
+		i := strings.IndexByte(text, '@')
+		if i < 0 {
+			return names
+		}
+		text = text[i+1:]
+		end := strings.IndexFunc(text, func(r rune) bool {
+			return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+		})
+		if end < 0 {
+			end = len(text)
+		}
+		if name := strings.ToLower(text[:end]); name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		text = text[end:]
+	}
+}
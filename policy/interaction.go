@@ -0,0 +1,157 @@
+package policy
+
+import (
+	"api-service/model"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// Виды взаимодействия между пользователями
+const (
+	ActionDirectMessage = "direct_message" // Написать в личные сообщения
+	ActionAddToGroup    = "add_to_group"   // Добавить в групповой чат
+	ActionSendMessage   = "send_message"   // Написать в уже созданный личный чат
+)
+
+// Причины отказа
+const (
+	ReasonActorUnavailable  = "actor_unavailable"  // Инициатор удалён или заблокирован администрацией
+	ReasonTargetNotFound    = "target_not_found"   // Пользователь удалён или не существует
+	ReasonTargetSuspended   = "target_suspended"   // Пользователь заблокирован администрацией
+	ReasonBlocked           = "blocked"            // Один из пользователей заблокировал другого
+	ReasonDMDisabled        = "dm_disabled"        // Пользователь отключил личные сообщения
+	ReasonFollowersOnly     = "followers_only"     // Писать могут только подписчики
+	ReasonPrivateAccount    = "private_account"    // Закрытый аккаунт
+	ReasonUnsupportedAction = "unsupported_action" // Неизвестное действие
+)
+
+// Decision — результат проверки; Reason заполняется при отказе
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+func allow() Decision             { return Decision{Allowed: true} }
+func deny(reason string) Decision { return Decision{Reason: reason} }
+
+type userState struct {
+	ID          int32  `bun:"id"`
+	IsPrivate   bool   `bun:"is_private"`
+	DMPolicy    string `bun:"dm_policy"`
+	IsSuspended bool   `bun:"is_suspended"`
+}
+
+type followState struct {
+	FollowerID int  `bun:"follower_id"`
+	FolloweeID int  `bun:"followee_id"`
+	Accepted   bool `bun:"accepted"`
+}
+
+// CanInteract решает, может ли actorID выполнить action по отношению к targetID.
+// Учитывает удалённые и заблокированные администрацией аккаунты, взаимные блокировки,
+// настройку личных сообщений и закрытые аккаунты. Для ActionSendMessage настройки
+// и подписки не проверяются: они действовали, когда личный чат создавался.
+func CanInteract(ctx context.Context, db bun.IDB, actorID, targetID int, action string) (Decision, error) {
+	if action != ActionDirectMessage && action != ActionAddToGroup && action != ActionSendMessage {
+		return deny(ReasonUnsupportedAction), nil
+	}
+
+	var users []userState
+	err := db.NewSelect().
+		Model((*model.User)(nil)).
+		Column("id", "is_private", "dm_policy").
+		ColumnExpr("suspended_at IS NOT NULL AS is_suspended").
+		Where("id IN (?)", bun.In([]int{actorID, targetID})).
+		Scan(ctx, &users)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	var actor, target *userState
+	for i := range users {
+		if int(users[i].ID) == actorID {
+			actor = &users[i]
+		}
+		if int(users[i].ID) == targetID {
+			target = &users[i]
+		}
+	}
+	if actor == nil || actor.IsSuspended {
+		return deny(ReasonActorUnavailable), nil
+	}
+	if target == nil {
+		return deny(ReasonTargetNotFound), nil
+	}
+	if target.IsSuspended {
+		return deny(ReasonTargetSuspended), nil
+	}
+	if actorID == targetID {
+		return allow(), nil
+	}
+
+	blocked, err := IsBlockedEitherWay(ctx, db, actorID, targetID)
+	if err != nil {
+		return Decision{}, err
+	}
+	if blocked {
+		return deny(ReasonBlocked), nil
+	}
+	if action == ActionSendMessage {
+		return allow(), nil
+	}
+
+	var follows []followState
+	err = db.NewSelect().
+		Model((*model.Follow)(nil)).
+		Column("follower_id", "followee_id", "accepted").
+		Where("(follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+			actorID, targetID, targetID, actorID).
+		Scan(ctx, &follows)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	var actorFollowsTarget, targetFollowsActor bool
+	for _, f := range follows {
+		if !f.Accepted {
+			continue
+		}
+		if f.FollowerID == actorID {
+			actorFollowsTarget = true
+		} else {
+			targetFollowsActor = true
+		}
+	}
+
+	switch action {
+	case ActionDirectMessage:
+		switch target.DMPolicy {
+		case model.DMPolicyNobody:
+			return deny(ReasonDMDisabled), nil
+		case model.DMPolicyFollowers:
+			if !actorFollowsTarget {
+				return deny(ReasonFollowersOnly), nil
+			}
+		}
+		// Закрытому аккаунту могут писать его подписчики и те, на кого он подписан сам
+		if target.IsPrivate && !actorFollowsTarget && !targetFollowsActor {
+			return deny(ReasonPrivateAccount), nil
+		}
+	case ActionAddToGroup:
+		// Закрытый аккаунт можно добавить в группу, только если он сам подписан на инициатора
+		if target.IsPrivate && !targetFollowsActor {
+			return deny(ReasonPrivateAccount), nil
+		}
+	}
+
+	return allow(), nil
+}
+
+// IsBlockedEitherWay проверяет, заблокировал ли кто-то из пользователей другого
+func IsBlockedEitherWay(ctx context.Context, db bun.IDB, a, b int) (bool, error) {
+	return db.NewSelect().
+		Model((*model.UserBlock)(nil)).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", a, b, b, a).
+		Exists(ctx)
+}
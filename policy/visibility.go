@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"api-service/model"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// CanViewPosts решает, может ли viewerID видеть посты authorID.
+// viewerID = 0 — анонимный пользователь. Посты закрытого аккаунта видят только
+// его подтверждённые подписчики, а блокировка в любую сторону скрывает посты.
+func CanViewPosts(ctx context.Context, db bun.IDB, viewerID, authorID int) (bool, error) {
+	if viewerID != 0 && viewerID == authorID {
+		return true, nil
+	}
+
+	if viewerID != 0 {
+		blocked, err := IsBlockedEitherWay(ctx, db, viewerID, authorID)
+		if err != nil {
+			return false, err
+		}
+		if blocked {
+			return false, nil
+		}
+	}
+
+	author := new(model.User)
+	err := db.NewSelect().Model(author).Column("id", "is_private").Where("id = ?", authorID).Scan(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !author.IsPrivate {
+		return true, nil
+	}
+	if viewerID == 0 {
+		return false, nil
+	}
+
+	return db.NewSelect().
+		Model((*model.Follow)(nil)).
+		Where("follower_id = ? AND followee_id = ?", viewerID, authorID).
+		Where("accepted = TRUE").
+		Exists(ctx)
+}
+
+// WherePostsVisible — то же правило, что CanViewPosts, в виде условия для списков:
+// оставляет строки, чей автор в authorColumn виден viewerID. Подключается через Apply
+func WherePostsVisible(viewerID int, authorColumn string) func(*bun.SelectQuery) *bun.SelectQuery {
+	author := bun.Ident(authorColumn)
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if viewerID == 0 {
+			return q.Where("? IN (SELECT id FROM users WHERE is_private = FALSE)", author)
+		}
+		return q.
+			Where("(? = ? OR ? IN (SELECT id FROM users WHERE is_private = FALSE) OR ? IN (?))",
+				author, viewerID, author, author,
+				q.NewSelect().
+					Model((*model.Follow)(nil)).
+					Column("followee_id").
+					Where("follower_id = ?", viewerID).
+					Where("accepted = TRUE")).
+			Where("NOT EXISTS (?)", q.NewSelect().
+				Model((*model.UserBlock)(nil)).
+				ColumnExpr("1").
+				Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)",
+					viewerID, author, author, viewerID))
+	}
+}
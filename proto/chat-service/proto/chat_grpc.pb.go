@@ -29,12 +29,15 @@ const (
 	ChatService_EditMessage_FullMethodName           = "/chat.ChatService/EditMessage"
 	ChatService_DeleteMessage_FullMethodName         = "/chat.ChatService/DeleteMessage"
 	ChatService_GetMessages_FullMethodName           = "/chat.ChatService/GetMessages"
+	ChatService_GetMessage_FullMethodName            = "/chat.ChatService/GetMessage"
 	ChatService_AddParticipant_FullMethodName        = "/chat.ChatService/AddParticipant"
 	ChatService_RemoveParticipant_FullMethodName     = "/chat.ChatService/RemoveParticipant"
 	ChatService_ListChatParticipants_FullMethodName  = "/chat.ChatService/ListChatParticipants"
 	ChatService_SetMessageReaction_FullMethodName    = "/chat.ChatService/SetMessageReaction"
 	ChatService_RemoveMessageReaction_FullMethodName = "/chat.ChatService/RemoveMessageReaction"
 	ChatService_MarkMessageAsRead_FullMethodName     = "/chat.ChatService/MarkMessageAsRead"
+	ChatService_SendTyping_FullMethodName            = "/chat.ChatService/SendTyping"
+	ChatService_SubscribeChatEvents_FullMethodName   = "/chat.ChatService/SubscribeChatEvents"
 )
 
 // ChatServiceClient is the client API for ChatService service.
@@ -50,12 +53,17 @@ type ChatServiceClient interface {
 	EditMessage(ctx context.Context, in *EditMessageRequest, opts ...grpc.CallOption) (*MessageResponse, error)
 	DeleteMessage(ctx context.Context, in *DeleteMessageRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	GetMessages(ctx context.Context, in *GetMessagesRequest, opts ...grpc.CallOption) (*ListMessagesResponse, error)
+	// Одно сообщение чата; NOT_FOUND, если сообщения нет или оно из другого чата
+	GetMessage(ctx context.Context, in *GetMessageRequest, opts ...grpc.CallOption) (*MessageResponse, error)
 	AddParticipant(ctx context.Context, in *AddParticipantRequest, opts ...grpc.CallOption) (*ChatResponse, error)
 	RemoveParticipant(ctx context.Context, in *RemoveParticipantRequest, opts ...grpc.CallOption) (*ChatResponse, error)
 	ListChatParticipants(ctx context.Context, in *ListChatParticipantsRequest, opts ...grpc.CallOption) (*ListParticipantsResponse, error)
 	SetMessageReaction(ctx context.Context, in *SetMessageReactionRequest, opts ...grpc.CallOption) (*MessageResponse, error)
 	RemoveMessageReaction(ctx context.Context, in *RemoveMessageReactionRequest, opts ...grpc.CallOption) (*MessageResponse, error)
 	MarkMessageAsRead(ctx context.Context, in *MarkMessageAsReadRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	SendTyping(ctx context.Context, in *SendTypingRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Поток событий чата; при переподключении передаётся after_event_id последнего полученного события
+	SubscribeChatEvents(ctx context.Context, in *SubscribeChatEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatEvent], error)
 }
 
 type chatServiceClient struct {
@@ -156,6 +164,16 @@ func (c *chatServiceClient) GetMessages(ctx context.Context, in *GetMessagesRequ
 	return out, nil
 }
 
+func (c *chatServiceClient) GetMessage(ctx context.Context, in *GetMessageRequest, opts ...grpc.CallOption) (*MessageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MessageResponse)
+	err := c.cc.Invoke(ctx, ChatService_GetMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *chatServiceClient) AddParticipant(ctx context.Context, in *AddParticipantRequest, opts ...grpc.CallOption) (*ChatResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ChatResponse)
@@ -216,6 +234,35 @@ func (c *chatServiceClient) MarkMessageAsRead(ctx context.Context, in *MarkMessa
 	return out, nil
 }
 
+func (c *chatServiceClient) SendTyping(ctx context.Context, in *SendTypingRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ChatService_SendTyping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) SubscribeChatEvents(ctx context.Context, in *SubscribeChatEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ChatService_ServiceDesc.Streams[0], ChatService_SubscribeChatEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeChatEventsRequest, ChatEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChatService_SubscribeChatEventsClient = grpc.ServerStreamingClient[ChatEvent]
+
 // ChatServiceServer is the server API for ChatService service.
 // All implementations must embed UnimplementedChatServiceServer
 // for forward compatibility.
@@ -229,12 +276,17 @@ type ChatServiceServer interface {
 	EditMessage(context.Context, *EditMessageRequest) (*MessageResponse, error)
 	DeleteMessage(context.Context, *DeleteMessageRequest) (*emptypb.Empty, error)
 	GetMessages(context.Context, *GetMessagesRequest) (*ListMessagesResponse, error)
+	// Одно сообщение чата; NOT_FOUND, если сообщения нет или оно из другого чата
+	GetMessage(context.Context, *GetMessageRequest) (*MessageResponse, error)
 	AddParticipant(context.Context, *AddParticipantRequest) (*ChatResponse, error)
 	RemoveParticipant(context.Context, *RemoveParticipantRequest) (*ChatResponse, error)
 	ListChatParticipants(context.Context, *ListChatParticipantsRequest) (*ListParticipantsResponse, error)
 	SetMessageReaction(context.Context, *SetMessageReactionRequest) (*MessageResponse, error)
 	RemoveMessageReaction(context.Context, *RemoveMessageReactionRequest) (*MessageResponse, error)
 	MarkMessageAsRead(context.Context, *MarkMessageAsReadRequest) (*emptypb.Empty, error)
+	SendTyping(context.Context, *SendTypingRequest) (*emptypb.Empty, error)
+	// Поток событий чата; при переподключении передаётся after_event_id последнего полученного события
+	SubscribeChatEvents(*SubscribeChatEventsRequest, grpc.ServerStreamingServer[ChatEvent]) error
 	mustEmbedUnimplementedChatServiceServer()
 }
 
@@ -272,6 +324,9 @@ func (UnimplementedChatServiceServer) DeleteMessage(context.Context, *DeleteMess
 func (UnimplementedChatServiceServer) GetMessages(context.Context, *GetMessagesRequest) (*ListMessagesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetMessages not implemented")
 }
+func (UnimplementedChatServiceServer) GetMessage(context.Context, *GetMessageRequest) (*MessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMessage not implemented")
+}
 func (UnimplementedChatServiceServer) AddParticipant(context.Context, *AddParticipantRequest) (*ChatResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddParticipant not implemented")
 }
@@ -290,6 +345,12 @@ func (UnimplementedChatServiceServer) RemoveMessageReaction(context.Context, *Re
 func (UnimplementedChatServiceServer) MarkMessageAsRead(context.Context, *MarkMessageAsReadRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method MarkMessageAsRead not implemented")
 }
+func (UnimplementedChatServiceServer) SendTyping(context.Context, *SendTypingRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendTyping not implemented")
+}
+func (UnimplementedChatServiceServer) SubscribeChatEvents(*SubscribeChatEventsRequest, grpc.ServerStreamingServer[ChatEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeChatEvents not implemented")
+}
 func (UnimplementedChatServiceServer) mustEmbedUnimplementedChatServiceServer() {}
 func (UnimplementedChatServiceServer) testEmbeddedByValue()                     {}
 
@@ -473,6 +534,24 @@ func _ChatService_GetMessages_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ChatService_GetMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).GetMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChatService_GetMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).GetMessage(ctx, req.(*GetMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ChatService_AddParticipant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(AddParticipantRequest)
 	if err := dec(in); err != nil {
@@ -581,6 +660,35 @@ func _ChatService_MarkMessageAsRead_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ChatService_SendTyping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendTypingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).SendTyping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChatService_SendTyping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).SendTyping(ctx, req.(*SendTypingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_SubscribeChatEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeChatEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChatServiceServer).SubscribeChatEvents(m, &grpc.GenericServerStream[SubscribeChatEventsRequest, ChatEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChatService_SubscribeChatEventsServer = grpc.ServerStreamingServer[ChatEvent]
+
 // ChatService_ServiceDesc is the grpc.ServiceDesc for ChatService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -624,6 +732,10 @@ var ChatService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetMessages",
 			Handler:    _ChatService_GetMessages_Handler,
 		},
+		{
+			MethodName: "GetMessage",
+			Handler:    _ChatService_GetMessage_Handler,
+		},
 		{
 			MethodName: "AddParticipant",
 			Handler:    _ChatService_AddParticipant_Handler,
@@ -648,7 +760,17 @@ var ChatService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "MarkMessageAsRead",
 			Handler:    _ChatService_MarkMessageAsRead_Handler,
 		},
+		{
+			MethodName: "SendTyping",
+			Handler:    _ChatService_SendTyping_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeChatEvents",
+			Handler:       _ChatService_SubscribeChatEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "chat.proto",
 }
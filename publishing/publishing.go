@@ -0,0 +1,162 @@
+// Package publishing публикует отложенные посты в назначенное время.
+// Публикация (сразу или по расписанию) ставит в очередь задачу PublishedJobKind:
+// её обработчики рассылают уведомления и раскладывают пост по лентам.
+package publishing
+
+import (
+	"api-service/jobs"
+	"api-service/model"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Виды фоновых задач
+const (
+	PublishJobKind   = "posts.publish"   // Опубликовать отложенный пост, время которого подошло
+	PublishedJobKind = "posts.published" // Пост опубликован
+)
+
+// Payload обеих задач. В периодической задаче PostID пустой — публикуются все просроченные посты
+type payload struct {
+	PostID int `json:"post_id,omitempty"`
+}
+
+// Listener вызывается из задачи PublishedJobKind для только что опубликованного поста.
+// Ошибка приводит к повтору задачи, поэтому обработчик должен быть идемпотентным
+type Listener func(ctx context.Context, post *model.Post) error
+
+// Schedule ставит публикацию поста на момент at. Вызывается в транзакции, которая
+// переводит пост в scheduled: если время потом изменится, старая задача ничего не сделает
+func Schedule(ctx context.Context, db bun.IDB, postID int, at time.Time) error {
+	_, err := jobs.Enqueue(ctx, db, PublishJobKind, payload{PostID: postID}, jobs.EnqueueOptions{
+		RunAt:     at,
+		UniqueKey: fmt.Sprintf("%s:%d:%d", PublishJobKind, postID, at.Unix()),
+	})
+	if errors.Is(err, jobs.ErrDuplicateJob) {
+		return nil
+	}
+	return err
+}
+
+// Published учитывает пост в счётчике цитат оригинала, если это цитата,
+// и ставит в очередь задачу о публикации. Вызывается в той же транзакции,
+// что и смена статуса на published
+func Published(ctx context.Context, db bun.IDB, postID int) error {
+	_, err := db.NewUpdate().
+		Model((*model.Post)(nil)).
+		WhereAllWithDeleted().
+		Set("quotes_count = quotes_count + 1").
+		Where("id = (?)", db.NewSelect().
+			Model((*model.Post)(nil)).
+			Column("quoted_post_id").
+			Where("id = ?", postID)).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update quotes count: %w", err)
+	}
+
+	_, err = jobs.Enqueue(ctx, db, PublishedJobKind, payload{PostID: postID}, jobs.EnqueueOptions{
+		UniqueKey: fmt.Sprintf("%s:%d", PublishedJobKind, postID),
+	})
+	if errors.Is(err, jobs.ErrDuplicateJob) {
+		return nil
+	}
+	return err
+}
+
+// PublishDue публикует отложенные посты, у которых наступило publish_at,
+// и возвращает их ID. postID = 0 — все такие посты, иначе только указанный
+func PublishDue(ctx context.Context, db *bun.DB, postID int) ([]int, error) {
+	var ids []int
+	err := db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		query := tx.NewUpdate().
+			Model((*model.Post)(nil)).
+			Set("status = ?", model.PostStatusPublished).
+			Set("published_at = publish_at").
+			Where("status = ?", model.PostStatusScheduled).
+			Where("publish_at <= ?", time.Now()).
+			Returning("id")
+		if postID != 0 {
+			query = query.Where("id = ?", postID)
+		}
+		if _, err := query.Exec(ctx, &ids); err != nil {
+			return fmt.Errorf("failed to publish posts: %w", err)
+		}
+
+		for _, id := range ids {
+			if err := Published(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func decodePayload(job *model.Job) (payload, error) {
+	var p payload
+	if err := json.Unmarshal(job.Payload, &p); err != nil {
+		return p, jobs.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+	return p, nil
+}
+
+// Register регистрирует обработчики в пуле и, если spec не пустой, периодическую
+// публикацию просроченных постов — на случай, если задача на конкретный пост потерялась
+func Register(pool *jobs.Pool, db *bun.DB, spec string, listeners ...Listener) error {
+	pool.Register(PublishJobKind, func(ctx context.Context, job *model.Job) error {
+		p, err := decodePayload(job)
+		if err != nil {
+			return err
+		}
+		ids, err := PublishDue(ctx, db, p.PostID)
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			log.Printf("Опубликованы отложенные посты: %v", ids)
+		}
+		return nil
+	})
+
+	pool.Register(PublishedJobKind, func(ctx context.Context, job *model.Job) error {
+		p, err := decodePayload(job)
+		if err != nil {
+			return err
+		}
+		post := new(model.Post)
+		err = db.NewSelect().
+			Model(post).
+			Where("id = ?", p.PostID).
+			Where("status = ?", model.PostStatusPublished).
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Пост успели удалить
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, listener := range listeners {
+			if err := listener(ctx, post); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if spec == "" {
+		return nil
+	}
+	return pool.Schedule(PublishJobKind, spec, PublishJobKind, nil)
+}
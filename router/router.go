@@ -3,41 +3,110 @@ package router
 import (
 	"api-service/handler"
 	"api-service/middleware"
+	"api-service/model"
 	"github.com/labstack/echo/v4"
 	"github.com/uptrace/bun"
 )
 
-func SetupRoutes(e *echo.Echo, userHandler *handler.UserHandler, postHandler *handler.PostHandler, db *bun.DB) {
+func SetupRoutes(e *echo.Echo, userHandler *handler.UserHandler, postHandler *handler.PostHandler, db *bun.DB, jwtSecret []byte, chatAvailable func() bool) {
+	// Маршруты, зависящие от chat-service, отвечают 503, пока он недоступен
+	requireChat := middleware.RequireService("chat-service", chatAvailable)
+
 	// Публичные маршруты
 	e.POST("/register", userHandler.Register)
 	e.POST("/login", userHandler.Login)
 
 	// Группа защищенных маршрутов
 	authGroup := e.Group("")
-	authGroup.Use(middleware.JWTMiddleware(db)) // Добавляем middleware для всех защищенных маршрутов
+	authGroup.Use(middleware.JWTMiddleware(db, jwtSecret)) // Добавляем middleware для всех защищенных маршрутов
 
 	// Защищенные маршруты для пользователей
 	authGroup.GET("/users", userHandler.GetUsers)          // Получить список пользователей
 	authGroup.PUT("/users", userHandler.UpdateUser)        // Обновить текущего пользователя
 	authGroup.DELETE("/users", userHandler.DeleteUser)     // Удалить текущего пользователя
 
-	// Публичные маршруты для постов
-	e.GET("/posts", postHandler.GetPosts)
-	e.GET("/posts/:id", postHandler.GetPostByID)
-	e.GET("/posts/:id/comments", postHandler.GetCommentsByPostID)
-	e.GET("/tags", postHandler.GetAllTags)
-	e.GET("/users/:user_id/posts", postHandler.GetUserPosts)
+	// Подписки и блокировки: по ним policy решает, кто видит посты и может писать в чаты
+	authGroup.POST("/users/:user_id/follow", userHandler.FollowUser)
+	authGroup.DELETE("/users/:user_id/follow", userHandler.UnfollowUser)
+	authGroup.POST("/users/:user_id/block", userHandler.BlockUser)
+	authGroup.DELETE("/users/:user_id/block", userHandler.UnblockUser)
+	authGroup.POST("/me/follow-requests/:user_id/accept", userHandler.AcceptFollowRequest)
+
+	// Публичные маршруты для постов. Токен необязателен: если он есть, закрытые
+	// посты видны подписчикам, а блокировки скрывают посты от заблокированных
+	publicGroup := e.Group("", middleware.OptionalJWTMiddleware(jwtSecret))
+	publicGroup.GET("/posts", postHandler.GetPosts)
+	publicGroup.GET("/posts/:id", postHandler.GetPostByID)
+	publicGroup.GET("/posts/:id/comments", postHandler.GetCommentsByPostID)
+	publicGroup.GET("/posts/:id/revisions", postHandler.GetPostRevisions)
+	publicGroup.GET("/posts/:id/quotes", postHandler.GetPostQuotes)
+	publicGroup.GET("/posts/:id/thread", postHandler.GetPostThread)
+	publicGroup.GET("/posts/:id/reactions", postHandler.GetPostReactions)
+	publicGroup.GET("/comments/:comment_id/likes", postHandler.GetCommentLikes)
+	publicGroup.GET("/tags", postHandler.GetAllTags)
+	publicGroup.GET("/users/:user_id/posts", postHandler.GetUserPosts)
 
 	// Защищенные маршруты для постов
 	authGroup.POST("/posts", postHandler.CreatePost)
+	authGroup.POST("/posts/thread", postHandler.CreateThread)
 	authGroup.PUT("/posts/:id", postHandler.UpdatePost)
+	authGroup.PATCH("/posts/:id", postHandler.PatchPost)
+	authGroup.POST("/posts/:id/revisions/:version/restore", postHandler.RestorePostRevision)
 	authGroup.DELETE("/posts/:id", postHandler.DeletePost)
+	authGroup.POST("/posts/:id/restore", postHandler.RestorePost)
 	authGroup.POST("/posts/:id/like", postHandler.LikePost)
+	authGroup.PUT("/posts/:id/reaction", postHandler.SetReaction)
+	authGroup.DELETE("/posts/:id/reaction", postHandler.RemoveReaction)
 	authGroup.POST("/posts/:id/comment", postHandler.CommentOnPost)
 	authGroup.PUT("/comments/:comment_id", postHandler.UpdateComment)
 	authGroup.DELETE("/posts/:post_id/comment/:comment_id", postHandler.DeleteComment)
+	authGroup.POST("/comments/:comment_id/restore", postHandler.RestoreComment)
+	authGroup.POST("/comments/:comment_id/like", postHandler.LikeComment)
+	authGroup.PUT("/posts/:id/comments-policy", postHandler.SetCommentsPolicy)
+	authGroup.PUT("/posts/:id/pinned-comment", postHandler.PinComment)
+	authGroup.DELETE("/posts/:id/pinned-comment", postHandler.UnpinComment)
+	authGroup.POST("/posts/:post_id/comment/:comment_id/hide", postHandler.HideComment)
+	authGroup.DELETE("/posts/:post_id/comment/:comment_id/hide", postHandler.UnhideComment)
+	authGroup.GET("/posts/:id/comments/hidden", postHandler.GetHiddenComments)
+	authGroup.GET("/me/trash", postHandler.GetTrash)
+	authGroup.GET("/me/drafts", postHandler.GetDrafts)
+	authGroup.GET("/me/scheduled", postHandler.GetScheduled)
 	authGroup.POST("/posts/:id/repost", postHandler.RepostPost)
 	authGroup.DELETE("/posts/:id/repost", postHandler.DeleteRepost)
+	authGroup.POST("/posts/:id/share", postHandler.SharePost, requireChat)
+
+	// Служебные маршруты для администраторов
+	adminGroup := authGroup.Group("/admin", middleware.RequireRole(db, model.RoleAdmin))
+	adminGroup.POST("/counters/reconcile", postHandler.ReconcileCounters)
+	adminGroup.POST("/users/:user_id/suspend", userHandler.SuspendUser)
+	adminGroup.DELETE("/users/:user_id/suspend", userHandler.UnsuspendUser)
+
+	// Защищенные маршруты для чатов (шлюз к chat-service)
+	chatGroup := authGroup.Group("/chats", requireChat)
+	chatGroup.POST("", userHandler.CreateChat)
+	chatGroup.GET("", userHandler.ListMyChats)
+	chatGroup.GET("/inbox", userHandler.ChatInbox)
+	chatGroup.GET("/:id", userHandler.GetChat)
+	chatGroup.PUT("/:id", userHandler.UpdateChat)
+	chatGroup.DELETE("/:id", userHandler.DeleteChat)
+	chatGroup.POST("/:id/messages", userHandler.SendMessage)
+	chatGroup.GET("/:id/messages", userHandler.GetMessages)
+	chatGroup.PUT("/:id/messages/:message_id", userHandler.EditMessage)
+	chatGroup.DELETE("/:id/messages/:message_id", userHandler.DeleteMessage)
+	chatGroup.PUT("/:id/messages/:message_id/reaction", userHandler.SetMessageReaction)
+	chatGroup.DELETE("/:id/messages/:message_id/reaction", userHandler.RemoveMessageReaction)
+	chatGroup.POST("/:id/messages/:message_id/read", userHandler.MarkMessageAsRead)
+	chatGroup.GET("/:id/participants", userHandler.ListChatParticipants)
+	chatGroup.POST("/:id/participants", userHandler.AddParticipant)
+	chatGroup.DELETE("/:id/participants/:user_id", userHandler.RemoveParticipant)
+	chatGroup.POST("/:id/attachments", userHandler.UploadChatAttachment)
+	chatGroup.GET("/:id/attachments/:attachment_id", userHandler.GetChatAttachment)
+
+	// Скачивание вложений по подписанной ссылке, без заголовка Authorization
+	e.GET("/files/chat/:attachment_id", userHandler.ServeChatAttachment, requireChat)
+
+	// WebSocket с событиями чата: токен можно передать в ?token=
+	e.GET("/chats/:id/ws", userHandler.ChatEventsWS, middleware.TokenFromQuery, middleware.JWTMiddleware(db, jwtSecret), requireChat)
 }
 
 // package router
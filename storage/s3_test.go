@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Интеграционный тест против настоящего MinIO. Запускается, только если задан
+// S3_TEST_ENDPOINT, например:
+//
+//	docker run -d -p 9000:9000 minio/minio server /data
+//	S3_TEST_ENDPOINT=localhost:9000 go test ./storage -run TestS3
+//
+// Ключи по умолчанию — minioadmin/minioadmin, их можно переопределить через
+// S3_TEST_ACCESS_KEY и S3_TEST_SECRET_KEY.
+func newTestS3(t *testing.T, ctx context.Context) *S3 {
+	t.Helper()
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT не задан, интеграционный тест MinIO пропущен")
+	}
+	cfg := S3Config{
+		Endpoint:  endpoint,
+		AccessKey: envOr("S3_TEST_ACCESS_KEY", "minioadmin"),
+		SecretKey: envOr("S3_TEST_SECRET_KEY", "minioadmin"),
+		// Отдельный бакет на каждый запуск, чтобы тесты не мешали друг другу
+		Bucket: fmt.Sprintf("api-service-test-%d", time.Now().UnixNano()),
+		UseSSL: os.Getenv("S3_TEST_USE_SSL") == "true",
+	}
+
+	s, err := NewS3(ctx, cfg)
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.client.RemoveBucket(ctx, cfg.Bucket); err != nil {
+			t.Logf("не удалось удалить бакет %s: %v", cfg.Bucket, err)
+		}
+	})
+	return s
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func TestS3PutGetDelete(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s := newTestS3(t, ctx)
+
+	const key = "chats/chat-1/att-1"
+	content := "hello, attachments"
+	if err := s.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	reader, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		t.Fatalf("чтение объекта: %v", err)
+	}
+	if string(got) != content {
+		t.Fatalf("Get вернул %q, ожидалось %q", got, content)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get после Delete: ожидалась ErrNotFound, получено %v", err)
+	}
+}
+
+func TestS3GetMissing(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s := newTestS3(t, ctx)
+
+	if _, err := s.Get(ctx, "chats/missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ожидалась ErrNotFound, получено %v", err)
+	}
+}
+
+func TestNewS3ReusesExistingBucket(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s := newTestS3(t, ctx)
+
+	// Повторный старт сервиса с тем же бакетом не должен падать
+	again, err := NewS3(ctx, S3Config{
+		Endpoint:  os.Getenv("S3_TEST_ENDPOINT"),
+		AccessKey: envOr("S3_TEST_ACCESS_KEY", "minioadmin"),
+		SecretKey: envOr("S3_TEST_SECRET_KEY", "minioadmin"),
+		Bucket:    s.bucket,
+		UseSSL:    os.Getenv("S3_TEST_USE_SSL") == "true",
+	})
+	if err != nil {
+		t.Fatalf("NewS3 для существующего бакета: %v", err)
+	}
+	if again.bucket != s.bucket {
+		t.Fatalf("бакет %q, ожидался %q", again.bucket, s.bucket)
+	}
+}
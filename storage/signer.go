@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Signer подписывает ссылки на файлы: подпись привязана к ресурсу,
+// пользователю, которому выдана ссылка, и времени истечения
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewSigner создаёт подписчика с ключом secret и временем жизни ссылок ttl
+func NewSigner(secret []byte, ttl time.Duration) *Signer {
+	return &Signer{secret: secret, ttl: ttl}
+}
+
+func (s *Signer) mac(resource string, userID int, expires int64) string {
+	m := hmac.New(sha256.New, s.secret)
+	m.Write([]byte(resource + "\n" + strconv.Itoa(userID) + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// Sign возвращает время истечения и подпись ссылки на resource для userID
+func (s *Signer) Sign(resource string, userID int) (expires int64, signature string) {
+	expires = time.Now().Add(s.ttl).Unix()
+	return expires, s.mac(resource, userID, expires)
+}
+
+// Verify проверяет подпись и что срок действия ссылки не истёк
+func (s *Signer) Verify(resource string, userID int, expires int64, signature string) bool {
+	if time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.mac(resource, userID, expires)))
+}
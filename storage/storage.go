@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound — объекта с таким ключом нет
+var ErrNotFound = errors.New("storage: object not found")
+
+// Blob — хранилище файлов по ключу. Метаданные (тип, имя, размер) хранятся в БД,
+// поэтому хранилищу достаточно содержимого.
+type Blob interface {
+	// Put сохраняет содержимое r размером size под ключом key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get открывает объект на чтение; вызывающий закрывает reader
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет объект; отсутствие объекта ошибкой не считается
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,67 @@
+// Package trash окончательно удаляет посты и комментарии, пролежавшие в корзине
+// дольше срока хранения. Лайки, репосты, теги и медиа удаляемых постов
+// удаляются каскадно внешними ключами.
+package trash
+
+import (
+	"api-service/jobs"
+	"api-service/model"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// JobKind — вид фоновой задачи очистки корзины
+const JobKind = "trash.purge"
+
+// Purge удаляет посты и комментарии, удалённые в корзину раньше, чем retention назад
+func Purge(ctx context.Context, db *bun.DB, retention time.Duration) (posts, comments int64, err error) {
+	cutoff := time.Now().Add(-retention)
+
+	res, err := db.NewDelete().
+		Model((*model.Post)(nil)).
+		WhereDeleted().
+		Where("deleted_at < ?", cutoff).
+		ForceDelete().
+		Exec(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to purge posts: %w", err)
+	}
+	posts, _ = res.RowsAffected()
+
+	// Комментарии удалённых постов ушли каскадно, здесь — удалённые по отдельности
+	res, err = db.NewDelete().
+		Model((*model.Comment)(nil)).
+		WhereDeleted().
+		Where("deleted_at < ?", cutoff).
+		ForceDelete().
+		Exec(ctx)
+	if err != nil {
+		return posts, 0, fmt.Errorf("failed to purge comments: %w", err)
+	}
+	comments, _ = res.RowsAffected()
+	return posts, comments, nil
+}
+
+// Register регистрирует обработчик очистки в пуле и, если spec не пустой,
+// периодический запуск по cron-расписанию
+func Register(pool *jobs.Pool, db *bun.DB, spec string, retention time.Duration) error {
+	pool.Register(JobKind, func(ctx context.Context, job *model.Job) error {
+		posts, comments, err := Purge(ctx, db, retention)
+		if err != nil {
+			return err
+		}
+		if posts > 0 || comments > 0 {
+			log.Printf("Очистка корзины: удалено постов %d, комментариев %d", posts, comments)
+		}
+		return nil
+	})
+
+	if spec == "" {
+		return nil
+	}
+	return pool.Schedule(JobKind, spec, JobKind, nil)
+}
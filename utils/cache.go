@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// TTLCache — потокобезопасный кэш в памяти с временем жизни записей.
+// При переполнении вытесняются просроченные записи, а если их нет — произвольные.
+type TTLCache[K comparable, V any] struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	maxSize int
+	items   map[K]cacheEntry[V]
+}
+
+// NewTTLCache создаёт кэш с временем жизни ttl и не более maxSize записей
+func NewTTLCache[K comparable, V any](ttl time.Duration, maxSize int) *TTLCache[K, V] {
+	return &TTLCache[K, V]{
+		ttl:     ttl,
+		maxSize: maxSize,
+		items:   make(map[K]cacheEntry[V]),
+	}
+}
+
+// Get возвращает значение, если оно есть и не просрочено
+func (c *TTLCache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	entry, ok := c.items[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Set сохраняет значение
+func (c *TTLCache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.items[key]; !exists && c.maxSize > 0 && len(c.items) >= c.maxSize {
+		c.evictLocked()
+	}
+	c.items[key] = cacheEntry[V]{value: value, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// Delete удаляет значение, например после изменения данных
+func (c *TTLCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
+
+func (c *TTLCache[K, V]) evictLocked() {
+	now := time.Now()
+	for key, entry := range c.items {
+		if now.After(entry.expiresAt) {
+			delete(c.items, key)
+		}
+	}
+	// Просроченных не нашлось — освобождаем место под одну запись
+	if len(c.items) >= c.maxSize {
+		for key := range c.items {
+			delete(c.items, key)
+			break
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import "strings"
+
+// Операции построчного диффа
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// Выше этого произведения числа строк LCS не считается: текст целиком
+// помечается как удалённый и вставленный
+const maxDiffCells = 1_000_000
+
+// DiffOp — строка диффа
+type DiffOp struct {
+	Op   string
+	Text string
+}
+
+// DiffLines сравнивает два текста построчно по наибольшей общей подпоследовательности
+func DiffLines(a, b string) []DiffOp {
+	x, y := splitLines(a), splitLines(b)
+
+	if len(x)*len(y) > maxDiffCells {
+		ops := make([]DiffOp, 0, len(x)+len(y))
+		for _, line := range x {
+			ops = append(ops, DiffOp{Op: DiffDelete, Text: line})
+		}
+		for _, line := range y {
+			ops = append(ops, DiffOp{Op: DiffInsert, Text: line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] — длина общей подпоследовательности x[i:] и y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []DiffOp
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			ops = append(ops, DiffOp{Op: DiffEqual, Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, DiffOp{Op: DiffDelete, Text: x[i]})
+			i++
+		default:
+			ops = append(ops, DiffOp{Op: DiffInsert, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		ops = append(ops, DiffOp{Op: DiffDelete, Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		ops = append(ops, DiffOp{Op: DiffInsert, Text: y[j]})
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
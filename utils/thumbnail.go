@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Декодер WebP для image.Decode
+)
+
+// Ограничение на размер декодируемого изображения, чтобы не распаковывать «бомбы»
+const maxImagePixels = 40_000_000
+
+// ErrImageTooLarge — изображение слишком большое для обработки
+var ErrImageTooLarge = errors.New("image is too large")
+
+// Thumbnail — уменьшенная копия изображения
+type Thumbnail struct {
+	Data        []byte
+	ContentType string
+	Width       int // Размеры исходного изображения
+	Height      int
+}
+
+// MakeThumbnail уменьшает изображение так, чтобы большая сторона была не больше maxSide.
+// JPEG остаётся JPEG, остальные форматы кодируются в PNG, чтобы сохранить прозрачность.
+func MakeThumbnail(r io.ReadSeeker, maxSide int) (*Thumbnail, error) {
+	cfg, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var src image.Image
+	if format == "gif" {
+		src, err = gif.Decode(r) // Первый кадр анимации
+	} else {
+		src, _, err = image.Decode(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := cfg.Width, cfg.Height
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			w, h = maxSide, h*maxSide/w
+		} else {
+			w, h = w*maxSide/h, maxSide
+		}
+	}
+	w, h = max(w, 1), max(h, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	thumb := &Thumbnail{Width: cfg.Width, Height: cfg.Height}
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80})
+		thumb.ContentType = "image/jpeg"
+	} else {
+		err = png.Encode(&buf, dst)
+		thumb.ContentType = "image/png"
+	}
+	if err != nil {
+		return nil, err
+	}
+	thumb.Data = buf.Bytes()
+	return thumb, nil
+}